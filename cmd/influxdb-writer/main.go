@@ -0,0 +1,340 @@
+// Copyright (c) Mainflux
+// SPDX-License-Identifier: Apache-2.0
+
+package main
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"net/http"
+	"os"
+	"os/signal"
+	"strconv"
+	"syscall"
+	"time"
+
+	influxdata "github.com/influxdata/influxdb-client-go/v2"
+	"github.com/mainflux/mainflux"
+	"github.com/mainflux/mainflux/consumers"
+	writer "github.com/mainflux/mainflux/consumers/writers/influxdb"
+	"github.com/mainflux/mainflux/consumers/writers/influxdb/pipeline"
+	"github.com/mainflux/mainflux/logger"
+	"github.com/mainflux/mainflux/pkg/messaging"
+	"github.com/mainflux/mainflux/pkg/messaging/kafka"
+	"github.com/mainflux/mainflux/pkg/messaging/nats"
+	"github.com/mainflux/mainflux/pkg/transformers"
+	"github.com/mainflux/mainflux/pkg/transformers/cbor"
+	"github.com/mainflux/mainflux/pkg/transformers/json"
+	"github.com/mainflux/mainflux/pkg/transformers/msgpack"
+	"github.com/mainflux/mainflux/pkg/transformers/senml"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+)
+
+const (
+	defLogLevel       = "error"
+	defConfigPath     = "/config.toml"
+	defHTTPPort       = "8904"
+	defOrg            = "mainflux"
+	defBucket         = "mainflux"
+	defToken          = ""
+	defInfluxURL      = "http://localhost:9999"
+	defContentType    = senmlJSONContentType
+	defBatchSize      = "500"
+	defFlushInterval  = "1s"
+	defQueueSize      = "5000"
+	defSpoolPath      = ""
+	defReconcileEvery = "1h"
+
+	defBrokerType = "nats"
+	defBrokerURL  = "nats://localhost:4222"
+
+	defKafkaSASLUsername = ""
+	defKafkaSASLPassword = ""
+	defKafkaTLS          = "false"
+	defKafkaCACerts      = ""
+
+	envLogLevel       = "MF_INFLUX_WRITER_LOG_LEVEL"
+	envConfigPath     = "MF_INFLUX_WRITER_CONFIG_PATH"
+	envHTTPPort       = "MF_INFLUX_WRITER_HTTP_PORT"
+	envOrg            = "MF_INFLUXDB_ORG"
+	envBucket         = "MF_INFLUXDB_BUCKET"
+	envToken          = "MF_INFLUXDB_TOKEN"
+	envInfluxURL      = "MF_INFLUXDB_URL"
+	envContentType    = "MF_INFLUX_WRITER_CONTENT_TYPE"
+	envBatchSize      = "MF_INFLUX_WRITER_BATCH_SIZE"
+	envFlushInterval  = "MF_INFLUX_WRITER_FLUSH_INTERVAL"
+	envQueueSize      = "MF_INFLUX_WRITER_QUEUE_SIZE"
+	envSpoolPath      = "MF_INFLUX_WRITER_SPOOL_PATH"
+	envReconcileEvery = "MF_INFLUX_WRITER_RECONCILE_INTERVAL"
+
+	envBrokerType = "MF_BROKER_TYPE"
+	envBrokerURL  = "MF_BROKER_URL"
+
+	envKafkaSASLUsername = "MF_KAFKA_SASL_USERNAME"
+	envKafkaSASLPassword = "MF_KAFKA_SASL_PASSWORD"
+	envKafkaTLS          = "MF_KAFKA_TLS"
+	envKafkaCACerts      = "MF_KAFKA_CA_CERTS"
+
+	senmlJSONContentType    = "application/senml+json"
+	senmlCBORContentType    = "application/senml+cbor"
+	senmlMsgpackContentType = "application/senml+msgpack"
+	jsonContentType         = "application/json"
+)
+
+type config struct {
+	brokerType     string
+	brokerURL      string
+	logLevel       string
+	configPath     string
+	httpPort       string
+	org            string
+	bucket         string
+	token          string
+	influxURL      string
+	contentType    string
+	batchCfg       writer.BatchConfig
+	reconcileEvery time.Duration
+	kafkaCfg       kafka.Config
+}
+
+func main() {
+	cfg := loadConfig()
+
+	logger, err := logger.New(os.Stdout, cfg.logLevel)
+	if err != nil {
+		log.Fatalf(err.Error())
+	}
+
+	if cfg.batchCfg.SpoolPath == "" {
+		logger.Warn(fmt.Sprintf("%s is not set: batches that fail to flush to InfluxDB will be dropped instead of spooled to disk", envSpoolPath))
+	}
+
+	pubSub, err := createPubSub(cfg, logger)
+	if err != nil {
+		logger.Error(fmt.Sprintf("Failed to connect to message broker: %s", err))
+		os.Exit(1)
+	}
+	defer pubSub.Close()
+
+	client := influxdata.NewClient(cfg.influxURL, cfg.token)
+	defer client.Close()
+
+	transformer, err := makeTransformer(cfg.contentType)
+	if err != nil {
+		logger.Error(fmt.Sprintf("Failed to create transformer: %s", err))
+		os.Exit(1)
+	}
+
+	stages, err := loadPipeline(cfg.configPath)
+	if err != nil {
+		logger.Error(fmt.Sprintf("Failed to load pipeline config: %s", err))
+		os.Exit(1)
+	}
+
+	repo, err := writer.New(client, cfg.org, cfg.bucket, cfg.token, cfg.influxURL, stages, cfg.batchCfg, logger)
+	if err != nil {
+		logger.Error(fmt.Sprintf("Failed to create InfluxDB writer: %s", err))
+		os.Exit(1)
+	}
+
+	errs := make(chan error, 2)
+
+	if err := consumers.Start(pubSub, repo, transformer, cfg.configPath, logger); err != nil {
+		logger.Error(fmt.Sprintf("Failed to start InfluxDB writer: %s", err))
+	}
+
+	registry := prometheus.NewRegistry()
+	if bw, ok := repo.(interface{ Metrics() []prometheus.Collector }); ok {
+		registry.MustRegister(bw.Metrics()...)
+	}
+
+	retentionMgr := startRetention(client, cfg, logger)
+	if retentionMgr != nil {
+		registry.MustRegister(retentionMgr.Metrics()...)
+	}
+
+	go startHTTPServer(registry, cfg.httpPort, logger, errs)
+
+	go func() {
+		c := make(chan os.Signal)
+		signal.Notify(c, syscall.SIGINT)
+		errs <- fmt.Errorf("%s", <-c)
+	}()
+
+	err = <-errs
+	logger.Error(fmt.Sprintf("InfluxDB writer service terminated: %s", err))
+}
+
+func loadConfig() config {
+	batchSize, err := strconv.Atoi(mainflux.Env(envBatchSize, defBatchSize))
+	if err != nil {
+		log.Fatalf("Invalid value passed for %s", envBatchSize)
+	}
+	flushInterval, err := time.ParseDuration(mainflux.Env(envFlushInterval, defFlushInterval))
+	if err != nil {
+		log.Fatalf("Invalid value passed for %s", envFlushInterval)
+	}
+	queueSize, err := strconv.Atoi(mainflux.Env(envQueueSize, defQueueSize))
+	if err != nil {
+		log.Fatalf("Invalid value passed for %s", envQueueSize)
+	}
+	reconcileEvery, err := time.ParseDuration(mainflux.Env(envReconcileEvery, defReconcileEvery))
+	if err != nil {
+		log.Fatalf("Invalid value passed for %s", envReconcileEvery)
+	}
+	kafkaTLS, err := strconv.ParseBool(mainflux.Env(envKafkaTLS, defKafkaTLS))
+	if err != nil {
+		log.Fatalf("Invalid value passed for %s", envKafkaTLS)
+	}
+
+	return config{
+		brokerType:  mainflux.Env(envBrokerType, defBrokerType),
+		brokerURL:   mainflux.Env(envBrokerURL, defBrokerURL),
+		logLevel:    mainflux.Env(envLogLevel, defLogLevel),
+		configPath:  mainflux.Env(envConfigPath, defConfigPath),
+		httpPort:    mainflux.Env(envHTTPPort, defHTTPPort),
+		org:         mainflux.Env(envOrg, defOrg),
+		bucket:      mainflux.Env(envBucket, defBucket),
+		token:       mainflux.Env(envToken, defToken),
+		influxURL:   mainflux.Env(envInfluxURL, defInfluxURL),
+		contentType: mainflux.Env(envContentType, defContentType),
+		batchCfg: writer.BatchConfig{
+			BatchSize:     batchSize,
+			FlushInterval: flushInterval,
+			QueueSize:     queueSize,
+			SpoolPath:     mainflux.Env(envSpoolPath, defSpoolPath),
+		},
+		reconcileEvery: reconcileEvery,
+		kafkaCfg: kafka.Config{
+			SASLUsername: mainflux.Env(envKafkaSASLUsername, defKafkaSASLUsername),
+			SASLPassword: mainflux.Env(envKafkaSASLPassword, defKafkaSASLPassword),
+			TLSEnabled:   kafkaTLS,
+			TLSCACerts:   mainflux.Env(envKafkaCACerts, defKafkaCACerts),
+		},
+	}
+}
+
+func createPubSub(cfg config, logger logger.Logger) (messaging.PubSub, error) {
+	switch cfg.brokerType {
+	case "kafka":
+		kafkaCfg := cfg.kafkaCfg
+		kafkaCfg.Brokers = []string{cfg.brokerURL}
+		kafkaCfg.ConsumerGrp = "influxdb-writer"
+		return kafka.NewPubSubWithConfig(kafkaCfg, logger)
+	case "nats", "":
+		return nats.NewPubSub(cfg.brokerURL, "", logger)
+	default:
+		return nil, fmt.Errorf("unknown broker type %q, expected %q or %q", cfg.brokerType, "nats", "kafka")
+	}
+}
+
+// makeTransformer builds the transformer run ahead of the InfluxDB writer.
+// It dispatches each message by its own messaging.Message.ContentType, so a
+// single deployment can mix SenML JSON/CBOR/MessagePack (or plain JSON)
+// encodings on the same topic; defaultContentType is used only for messages
+// whose ContentType is empty or unrecognized, e.g. adapters that don't set
+// it.
+func makeTransformer(defaultContentType string) (transformers.Transformer, error) {
+	def, err := newTransformer(defaultContentType)
+	if err != nil {
+		return nil, err
+	}
+
+	byType := map[string]transformers.Transformer{
+		senmlJSONContentType:    senml.New(senmlJSONContentType),
+		senmlCBORContentType:    cbor.New(cbor.SenMLContentType),
+		senmlMsgpackContentType: msgpack.New(msgpack.SenMLContentType),
+		jsonContentType:         json.New(),
+		cbor.ContentType:        cbor.New(cbor.ContentType),
+		msgpack.ContentType:     msgpack.New(msgpack.ContentType),
+	}
+
+	return contentTypeDispatcher{byType: byType, def: def}, nil
+}
+
+func newTransformer(contentType string) (transformers.Transformer, error) {
+	switch contentType {
+	case senmlJSONContentType:
+		return senml.New(senmlJSONContentType), nil
+	case senmlCBORContentType:
+		return cbor.New(cbor.SenMLContentType), nil
+	case senmlMsgpackContentType:
+		return msgpack.New(msgpack.SenMLContentType), nil
+	case jsonContentType:
+		return json.New(), nil
+	case cbor.ContentType:
+		return cbor.New(cbor.ContentType), nil
+	case msgpack.ContentType:
+		return msgpack.New(msgpack.ContentType), nil
+	default:
+		return nil, fmt.Errorf("unknown content type %q", contentType)
+	}
+}
+
+// contentTypeDispatcher routes each message to the transformer registered
+// for its own Content-Type, falling back to def when the message doesn't
+// carry one or carries one unknown to this writer.
+type contentTypeDispatcher struct {
+	byType map[string]transformers.Transformer
+	def    transformers.Transformer
+}
+
+func (d contentTypeDispatcher) Transform(msg messaging.Message) (interface{}, error) {
+	if t, ok := d.byType[msg.ContentType]; ok {
+		return t.Transform(msg)
+	}
+	return d.def.Transform(msg)
+}
+
+func loadPipeline(configPath string) ([]pipeline.Transformer, error) {
+	cfg, err := pipeline.LoadConfig(configPath)
+	if err != nil {
+		return nil, err
+	}
+	// This binary has no things-service client wired in, so it cannot
+	// supply a MetadataFetcher. A config with an "enrich" stage is
+	// rejected by Build rather than constructing a stage that would
+	// panic on the first message.
+	return pipeline.Build(cfg, nil)
+}
+
+func startRetention(client influxdata.Client, cfg config, logger logger.Logger) *writer.RetentionManager {
+	rcfg, err := writer.LoadRetentionConfig(cfg.configPath)
+	if err != nil {
+		logger.Error(fmt.Sprintf("Failed to load retention config: %s", err))
+		return nil
+	}
+	if len(rcfg.Rollups) == 0 {
+		return nil
+	}
+
+	mgr := writer.NewRetentionManager(client, cfg.org, rcfg, logger)
+
+	go func() {
+		if err := mgr.Reconcile(context.Background()); err != nil {
+			logger.Error(fmt.Sprintf("Failed to reconcile retention policies: %s", err))
+		}
+
+		ticker := time.NewTicker(cfg.reconcileEvery)
+		defer ticker.Stop()
+
+		for range ticker.C {
+			if err := mgr.Reconcile(context.Background()); err != nil {
+				logger.Error(fmt.Sprintf("Failed to reconcile retention policies: %s", err))
+			}
+		}
+	}()
+
+	return mgr
+}
+
+func startHTTPServer(registry *prometheus.Registry, port string, logger logger.Logger, errs chan error) {
+	p := fmt.Sprintf(":%s", port)
+	mux := http.NewServeMux()
+	mux.Handle("/metrics", promhttp.HandlerFor(registry, promhttp.HandlerOpts{}))
+
+	logger.Info(fmt.Sprintf("InfluxDB writer service started, exposed port %s", port))
+	errs <- http.ListenAndServe(p, mux)
+}
@@ -27,6 +27,8 @@ import (
 	mfsmpp "github.com/mainflux/mainflux/consumers/notifiers/smpp"
 	"github.com/mainflux/mainflux/consumers/notifiers/tracing"
 	"github.com/mainflux/mainflux/logger"
+	"github.com/mainflux/mainflux/pkg/messaging"
+	"github.com/mainflux/mainflux/pkg/messaging/kafka"
 	"github.com/mainflux/mainflux/pkg/messaging/nats"
 	"github.com/mainflux/mainflux/pkg/ulid"
 	opentracing "github.com/opentracing/opentracing-go"
@@ -55,6 +57,9 @@ const (
 	defJaegerURL     = ""
 	defNatsURL       = "nats://localhost:4222"
 
+	defBrokerType = "nats"
+	defBrokerURL  = "nats://localhost:4222"
+
 	defSmppAddress    = ""
 	defSmppUsername   = ""
 	defSmppPassword   = ""
@@ -69,6 +74,11 @@ const (
 	defAuthURL     = "localhost:8181"
 	defAuthTimeout = "1s"
 
+	defKafkaSASLUsername = ""
+	defKafkaSASLPassword = ""
+	defKafkaTLS          = "false"
+	defKafkaCACerts      = ""
+
 	envLogLevel      = "MF_SMPP_NOTIFIER_LOG_LEVEL"
 	envDBHost        = "MF_SMPP_NOTIFIER_DB_HOST"
 	envDBPort        = "MF_SMPP_NOTIFIER_DB_PORT"
@@ -87,6 +97,9 @@ const (
 	envJaegerURL     = "MF_JAEGER_URL"
 	envNatsURL       = "MF_NATS_URL"
 
+	envBrokerType = "MF_BROKER_TYPE"
+	envBrokerURL  = "MF_BROKER_URL"
+
 	envSmppAddress    = "MF_SMPP_ADDRESS"
 	envSmppUsername   = "MF_SMPP_USERNAME"
 	envSmppPassword   = "MF_SMPP_PASSWORD"
@@ -100,9 +113,16 @@ const (
 	envAuthCACerts = "MF_AUTH_CA_CERTS"
 	envAuthURL     = "MF_AUTH_GRPC_URL"
 	envAuthTimeout = "MF_AUTH_GRPC_TIMEOUT"
+
+	envKafkaSASLUsername = "MF_KAFKA_SASL_USERNAME"
+	envKafkaSASLPassword = "MF_KAFKA_SASL_PASSWORD"
+	envKafkaTLS          = "MF_KAFKA_TLS"
+	envKafkaCACerts      = "MF_KAFKA_CA_CERTS"
 )
 
 type config struct {
+	brokerType  string
+	brokerURL   string
 	natsURL     string
 	configPath  string
 	logLevel    string
@@ -117,6 +137,7 @@ type config struct {
 	authCACerts string
 	authURL     string
 	authTimeout time.Duration
+	kafkaCfg    kafka.Config
 }
 
 func main() {
@@ -130,9 +151,9 @@ func main() {
 	db := connectToDB(cfg.dbConfig, logger)
 	defer db.Close()
 
-	pubSub, err := nats.NewPubSub(cfg.natsURL, "", logger)
+	pubSub, err := createPubSub(cfg, logger)
 	if err != nil {
-		logger.Error(fmt.Sprintf("Failed to connect to NATS: %s", err))
+		logger.Error(fmt.Sprintf("Failed to connect to message broker: %s", err))
 		os.Exit(1)
 	}
 	defer pubSub.Close()
@@ -181,6 +202,11 @@ func loadConfig() config {
 		log.Fatalf("Invalid value passed for %s\n", envAuthTLS)
 	}
 
+	kafkaTLS, err := strconv.ParseBool(mainflux.Env(envKafkaTLS, defKafkaTLS))
+	if err != nil {
+		log.Fatalf("Invalid value passed for %s", envKafkaTLS)
+	}
+
 	dbConfig := postgres.Config{
 		Host:        mainflux.Env(envDBHost, defDBHost),
 		Port:        mainflux.Env(envDBPort, defDBPort),
@@ -222,6 +248,8 @@ func loadConfig() config {
 	}
 
 	return config{
+		brokerType:  mainflux.Env(envBrokerType, defBrokerType),
+		brokerURL:   mainflux.Env(envBrokerURL, mainflux.Env(envNatsURL, defBrokerURL)),
 		logLevel:    mainflux.Env(envLogLevel, defLogLevel),
 		natsURL:     mainflux.Env(envNatsURL, defNatsURL),
 		configPath:  mainflux.Env(envConfigPath, defConfigPath),
@@ -236,10 +264,30 @@ func loadConfig() config {
 		authCACerts: mainflux.Env(envAuthCACerts, defAuthCACerts),
 		authURL:     mainflux.Env(envAuthURL, defAuthURL),
 		authTimeout: authTimeout,
+		kafkaCfg: kafka.Config{
+			SASLUsername: mainflux.Env(envKafkaSASLUsername, defKafkaSASLUsername),
+			SASLPassword: mainflux.Env(envKafkaSASLPassword, defKafkaSASLPassword),
+			TLSEnabled:   kafkaTLS,
+			TLSCACerts:   mainflux.Env(envKafkaCACerts, defKafkaCACerts),
+		},
 	}
 
 }
 
+func createPubSub(cfg config, logger logger.Logger) (messaging.PubSub, error) {
+	switch cfg.brokerType {
+	case "kafka":
+		kafkaCfg := cfg.kafkaCfg
+		kafkaCfg.Brokers = []string{cfg.brokerURL}
+		kafkaCfg.ConsumerGrp = "smpp-notifier"
+		return kafka.NewPubSubWithConfig(kafkaCfg, logger)
+	case "nats", "":
+		return nats.NewPubSub(cfg.brokerURL, "", logger)
+	default:
+		return nil, fmt.Errorf("unknown broker type %q, expected %q or %q", cfg.brokerType, "nats", "kafka")
+	}
+}
+
 func initJaeger(svcName, url string, logger logger.Logger) (opentracing.Tracer, io.Closer) {
 	if url == "" {
 		return opentracing.NoopTracer{}, ioutil.NopCloser(nil)
@@ -0,0 +1,406 @@
+// Copyright (c) Mainflux
+// SPDX-License-Identifier: Apache-2.0
+
+package main
+
+import (
+	"fmt"
+	"io"
+	"io/ioutil"
+	"log"
+	"net/http"
+	"os"
+	"os/signal"
+	"strconv"
+	"syscall"
+	"time"
+
+	kitprometheus "github.com/go-kit/kit/metrics/prometheus"
+	"github.com/jmoiron/sqlx"
+	"github.com/mainflux/mainflux"
+	authapi "github.com/mainflux/mainflux/auth/api/grpc"
+	"github.com/mainflux/mainflux/consumers"
+	"github.com/mainflux/mainflux/consumers/notifiers"
+	"github.com/mainflux/mainflux/consumers/notifiers/api"
+	"github.com/mainflux/mainflux/consumers/notifiers/postgres"
+	"github.com/mainflux/mainflux/consumers/notifiers/tracing"
+	"github.com/mainflux/mainflux/consumers/notifiers/webhook"
+	webhookapi "github.com/mainflux/mainflux/consumers/notifiers/webhook/api"
+	webhookpg "github.com/mainflux/mainflux/consumers/notifiers/webhook/postgres"
+	"github.com/mainflux/mainflux/logger"
+	"github.com/mainflux/mainflux/pkg/messaging"
+	"github.com/mainflux/mainflux/pkg/messaging/kafka"
+	"github.com/mainflux/mainflux/pkg/messaging/nats"
+	"github.com/mainflux/mainflux/pkg/ulid"
+	opentracing "github.com/opentracing/opentracing-go"
+	stdprometheus "github.com/prometheus/client_golang/prometheus"
+	migrate "github.com/rubenv/sql-migrate"
+	jconfig "github.com/uber/jaeger-client-go/config"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials"
+)
+
+const (
+	defLogLevel      = "error"
+	defDBHost        = "localhost"
+	defDBPort        = "5432"
+	defDBUser        = "mainflux"
+	defDBPass        = "mainflux"
+	defDB            = "subscriptions"
+	defConfigPath    = "/config.toml"
+	defDBSSLMode     = "disable"
+	defDBSSLCert     = ""
+	defDBSSLKey      = ""
+	defDBSSLRootCert = ""
+	defHTTPPort      = "8912"
+	defServerCert    = ""
+	defServerKey     = ""
+	defFrom          = ""
+	defJaegerURL     = ""
+
+	defBrokerType = "nats"
+	defBrokerURL  = "nats://localhost:4222"
+
+	defWebhookWorkers        = "10"
+	defWebhookRequestTimeout = "5s"
+	defWebhookMaxAttempts    = "5"
+	defWebhookBaseDelay      = "500ms"
+	defWebhookMaxDelay       = "30s"
+
+	defAuthTLS     = "false"
+	defAuthCACerts = ""
+	defAuthURL     = "localhost:8181"
+	defAuthTimeout = "1s"
+
+	defKafkaSASLUsername = ""
+	defKafkaSASLPassword = ""
+	defKafkaTLS          = "false"
+	defKafkaCACerts      = ""
+
+	envLogLevel      = "MF_WEBHOOK_NOTIFIER_LOG_LEVEL"
+	envDBHost        = "MF_WEBHOOK_NOTIFIER_DB_HOST"
+	envDBPort        = "MF_WEBHOOK_NOTIFIER_DB_PORT"
+	envDBUser        = "MF_WEBHOOK_NOTIFIER_DB_USER"
+	envDBPass        = "MF_WEBHOOK_NOTIFIER_DB_PASS"
+	envDB            = "MF_WEBHOOK_NOTIFIER_DB"
+	envConfigPath    = "MF_WEBHOOK_NOTIFIER_WRITER_CONFIG_PATH"
+	envDBSSLMode     = "MF_WEBHOOK_NOTIFIER_DB_SSL_MODE"
+	envDBSSLCert     = "MF_WEBHOOK_NOTIFIER_DB_SSL_CERT"
+	envDBSSLKey      = "MF_WEBHOOK_NOTIFIER_DB_SSL_KEY"
+	envDBSSLRootCert = "MF_WEBHOOK_NOTIFIER_DB_SSL_ROOT_CERT"
+	envHTTPPort      = "MF_WEBHOOK_NOTIFIER_HTTP_PORT"
+	envServerCert    = "MF_WEBHOOK_NOTIFIER_SERVER_CERT"
+	envServerKey     = "MF_WEBHOOK_NOTIFIER_SERVER_KEY"
+	envFrom          = "MF_WEBHOOK_NOTIFIER_SOURCE_ADDR"
+	envJaegerURL     = "MF_JAEGER_URL"
+
+	envBrokerType = "MF_BROKER_TYPE"
+	envBrokerURL  = "MF_BROKER_URL"
+
+	envWebhookWorkers        = "MF_WEBHOOK_WORKERS"
+	envWebhookRequestTimeout = "MF_WEBHOOK_REQUEST_TIMEOUT"
+	envWebhookMaxAttempts    = "MF_WEBHOOK_MAX_ATTEMPTS"
+	envWebhookBaseDelay      = "MF_WEBHOOK_RETRY_BASE_DELAY"
+	envWebhookMaxDelay       = "MF_WEBHOOK_RETRY_MAX_DELAY"
+
+	envAuthTLS     = "MF_AUTH_CLIENT_TLS"
+	envAuthCACerts = "MF_AUTH_CA_CERTS"
+	envAuthURL     = "MF_AUTH_GRPC_URL"
+	envAuthTimeout = "MF_AUTH_GRPC_TIMEOUT"
+
+	envKafkaSASLUsername = "MF_KAFKA_SASL_USERNAME"
+	envKafkaSASLPassword = "MF_KAFKA_SASL_PASSWORD"
+	envKafkaTLS          = "MF_KAFKA_TLS"
+	envKafkaCACerts      = "MF_KAFKA_CA_CERTS"
+)
+
+type config struct {
+	brokerType  string
+	brokerURL   string
+	configPath  string
+	logLevel    string
+	dbConfig    postgres.Config
+	webhookConf webhook.Config
+	from        string
+	httpPort    string
+	serverCert  string
+	serverKey   string
+	jaegerURL   string
+	authTLS     bool
+	authCACerts string
+	authURL     string
+	authTimeout time.Duration
+	kafkaCfg    kafka.Config
+}
+
+func main() {
+	cfg := loadConfig()
+
+	logger, err := logger.New(os.Stdout, cfg.logLevel)
+	if err != nil {
+		log.Fatalf(err.Error())
+	}
+
+	db := connectToDB(cfg.dbConfig, logger)
+	defer db.Close()
+
+	pubSub, err := createPubSub(cfg, logger)
+	if err != nil {
+		logger.Error(fmt.Sprintf("Failed to connect to message broker: %s", err))
+		os.Exit(1)
+	}
+	defer pubSub.Close()
+
+	authTracer, closer := initJaeger("auth", cfg.jaegerURL, logger)
+	defer closer.Close()
+
+	auth, close := connectToAuth(cfg, authTracer, logger)
+	if close != nil {
+		defer close()
+	}
+
+	tracer, closer := initJaeger("webhook-notifier", cfg.jaegerURL, logger)
+	defer closer.Close()
+
+	dbTracer, dbCloser := initJaeger("webhook-notifier_db", cfg.jaegerURL, logger)
+	defer dbCloser.Close()
+
+	dead := webhookpg.NewDeadLetterRepository(db)
+	subs := webhookpg.NewSubscriptionRepository(db)
+	notifier := webhook.New(cfg.webhookConf, subs, dead, webhook.NewMetrics())
+	svc := newService(db, dbTracer, auth, cfg, notifier, logger)
+	errs := make(chan error, 2)
+
+	if err = consumers.Start(pubSub, svc, nil, cfg.configPath, logger); err != nil {
+		logger.Error(fmt.Sprintf("Failed to create Postgres writer: %s", err))
+	}
+
+	go startHTTPServer(tracer, svc, dead, subs, notifier, cfg, logger, errs)
+
+	go func() {
+		c := make(chan os.Signal)
+		signal.Notify(c, syscall.SIGINT)
+		errs <- fmt.Errorf("%s", <-c)
+	}()
+
+	err = <-errs
+	logger.Error(fmt.Sprintf("Webhook notifier service terminated: %s", err))
+}
+
+func loadConfig() config {
+	authTimeout, err := time.ParseDuration(mainflux.Env(envAuthTimeout, defAuthTimeout))
+	if err != nil {
+		log.Fatalf("Invalid %s value: %s", envAuthTimeout, err.Error())
+	}
+
+	tls, err := strconv.ParseBool(mainflux.Env(envAuthTLS, defAuthTLS))
+	if err != nil {
+		log.Fatalf("Invalid value passed for %s\n", envAuthTLS)
+	}
+
+	kafkaTLS, err := strconv.ParseBool(mainflux.Env(envKafkaTLS, defKafkaTLS))
+	if err != nil {
+		log.Fatalf("Invalid value passed for %s", envKafkaTLS)
+	}
+
+	dbConfig := postgres.Config{
+		Host:        mainflux.Env(envDBHost, defDBHost),
+		Port:        mainflux.Env(envDBPort, defDBPort),
+		User:        mainflux.Env(envDBUser, defDBUser),
+		Pass:        mainflux.Env(envDBPass, defDBPass),
+		Name:        mainflux.Env(envDB, defDB),
+		SSLMode:     mainflux.Env(envDBSSLMode, defDBSSLMode),
+		SSLCert:     mainflux.Env(envDBSSLCert, defDBSSLCert),
+		SSLKey:      mainflux.Env(envDBSSLKey, defDBSSLKey),
+		SSLRootCert: mainflux.Env(envDBSSLRootCert, defDBSSLRootCert),
+	}
+
+	workers, err := strconv.Atoi(mainflux.Env(envWebhookWorkers, defWebhookWorkers))
+	if err != nil {
+		log.Fatalf("Invalid value passed for %s", envWebhookWorkers)
+	}
+	reqTimeout, err := time.ParseDuration(mainflux.Env(envWebhookRequestTimeout, defWebhookRequestTimeout))
+	if err != nil {
+		log.Fatalf("Invalid value passed for %s", envWebhookRequestTimeout)
+	}
+	maxAttempts, err := strconv.Atoi(mainflux.Env(envWebhookMaxAttempts, defWebhookMaxAttempts))
+	if err != nil {
+		log.Fatalf("Invalid value passed for %s", envWebhookMaxAttempts)
+	}
+	baseDelay, err := time.ParseDuration(mainflux.Env(envWebhookBaseDelay, defWebhookBaseDelay))
+	if err != nil {
+		log.Fatalf("Invalid value passed for %s", envWebhookBaseDelay)
+	}
+	maxDelay, err := time.ParseDuration(mainflux.Env(envWebhookMaxDelay, defWebhookMaxDelay))
+	if err != nil {
+		log.Fatalf("Invalid value passed for %s", envWebhookMaxDelay)
+	}
+
+	webhookConf := webhook.Config{
+		Workers:        workers,
+		RequestTimeout: reqTimeout,
+		DefaultRetry: webhook.RetryPolicy{
+			MaxAttempts: maxAttempts,
+			BaseDelay:   baseDelay,
+			MaxDelay:    maxDelay,
+		},
+	}
+
+	return config{
+		brokerType:  mainflux.Env(envBrokerType, defBrokerType),
+		brokerURL:   mainflux.Env(envBrokerURL, defBrokerURL),
+		logLevel:    mainflux.Env(envLogLevel, defLogLevel),
+		configPath:  mainflux.Env(envConfigPath, defConfigPath),
+		dbConfig:    dbConfig,
+		webhookConf: webhookConf,
+		from:        mainflux.Env(envFrom, defFrom),
+		httpPort:    mainflux.Env(envHTTPPort, defHTTPPort),
+		serverCert:  mainflux.Env(envServerCert, defServerCert),
+		serverKey:   mainflux.Env(envServerKey, defServerKey),
+		jaegerURL:   mainflux.Env(envJaegerURL, defJaegerURL),
+		authTLS:     tls,
+		authCACerts: mainflux.Env(envAuthCACerts, defAuthCACerts),
+		authURL:     mainflux.Env(envAuthURL, defAuthURL),
+		authTimeout: authTimeout,
+		kafkaCfg: kafka.Config{
+			SASLUsername: mainflux.Env(envKafkaSASLUsername, defKafkaSASLUsername),
+			SASLPassword: mainflux.Env(envKafkaSASLPassword, defKafkaSASLPassword),
+			TLSEnabled:   kafkaTLS,
+			TLSCACerts:   mainflux.Env(envKafkaCACerts, defKafkaCACerts),
+		},
+	}
+}
+
+func createPubSub(cfg config, logger logger.Logger) (messaging.PubSub, error) {
+	switch cfg.brokerType {
+	case "kafka":
+		kafkaCfg := cfg.kafkaCfg
+		kafkaCfg.Brokers = []string{cfg.brokerURL}
+		kafkaCfg.ConsumerGrp = "webhook-notifier"
+		return kafka.NewPubSubWithConfig(kafkaCfg, logger)
+	case "nats", "":
+		return nats.NewPubSub(cfg.brokerURL, "", logger)
+	default:
+		return nil, fmt.Errorf("unknown broker type %q, expected %q or %q", cfg.brokerType, "nats", "kafka")
+	}
+}
+
+func initJaeger(svcName, url string, logger logger.Logger) (opentracing.Tracer, io.Closer) {
+	if url == "" {
+		return opentracing.NoopTracer{}, ioutil.NopCloser(nil)
+	}
+
+	tracer, closer, err := jconfig.Configuration{
+		ServiceName: svcName,
+		Sampler: &jconfig.SamplerConfig{
+			Type:  "const",
+			Param: 1,
+		},
+		Reporter: &jconfig.ReporterConfig{
+			LocalAgentHostPort: url,
+			LogSpans:           true,
+		},
+	}.NewTracer()
+	if err != nil {
+		logger.Error(fmt.Sprintf("Failed to init Jaeger: %s", err))
+		os.Exit(1)
+	}
+
+	return tracer, closer
+}
+
+func connectToDB(dbConfig postgres.Config, logger logger.Logger) *sqlx.DB {
+	db, err := postgres.Connect(dbConfig)
+	if err != nil {
+		logger.Error(fmt.Sprintf("Failed to connect to postgres: %s", err))
+		os.Exit(1)
+	}
+
+	if _, err := migrate.Exec(db.DB, "postgres", webhookpg.Migration(), migrate.Up); err != nil {
+		logger.Error(fmt.Sprintf("Failed to run webhook notifier migrations: %s", err))
+		os.Exit(1)
+	}
+
+	return db
+}
+
+func connectToAuth(cfg config, tracer opentracing.Tracer, logger logger.Logger) (mainflux.AuthServiceClient, func() error) {
+	var opts []grpc.DialOption
+	if cfg.authTLS {
+		if cfg.authCACerts != "" {
+			tpc, err := credentials.NewClientTLSFromFile(cfg.authCACerts, "")
+			if err != nil {
+				logger.Error(fmt.Sprintf("Failed to create tls credentials: %s", err))
+				os.Exit(1)
+			}
+			opts = append(opts, grpc.WithTransportCredentials(tpc))
+		}
+	} else {
+		opts = append(opts, grpc.WithInsecure())
+		logger.Info("gRPC communication is not encrypted")
+	}
+
+	conn, err := grpc.Dial(cfg.authURL, opts...)
+	if err != nil {
+		logger.Error(fmt.Sprintf("Failed to connect to auth service: %s", err))
+		os.Exit(1)
+	}
+
+	return authapi.NewClient(tracer, conn, cfg.authTimeout), conn.Close
+}
+
+func newService(db *sqlx.DB, tracer opentracing.Tracer, auth mainflux.AuthServiceClient, c config, notifier webhook.Notifier, logger logger.Logger) notifiers.Service {
+	database := postgres.NewDatabase(db)
+	repo := tracing.New(postgres.New(database), tracer)
+	idp := ulid.New()
+	svc := notifiers.New(auth, repo, idp, notifier, c.from)
+	svc = api.LoggingMiddleware(svc, logger)
+	svc = api.MetricsMiddleware(
+		svc,
+		kitprometheus.NewCounterFrom(stdprometheus.CounterOpts{
+			Namespace: "notifier",
+			Subsystem: "webhook",
+			Name:      "request_count",
+			Help:      "Number of requests received.",
+		}, []string{"method"}),
+		kitprometheus.NewSummaryFrom(stdprometheus.SummaryOpts{
+			Namespace: "notifier",
+			Subsystem: "webhook",
+			Name:      "request_latency_microseconds",
+			Help:      "Total duration of requests in microseconds.",
+		}, []string{"method"}),
+	)
+	return svc
+}
+
+func startHTTPServer(tracer opentracing.Tracer, svc notifiers.Service, dead webhook.DeadLetterRepository, subs webhook.SubscriptionRepository, notifier webhook.Notifier, cfg config, logger logger.Logger, errs chan error) {
+	p := fmt.Sprintf(":%s", cfg.httpPort)
+	mux := http.NewServeMux()
+	mux.Handle("/", api.MakeHandler(svc, tracer))
+	// webhookapi's router handles its own /dead-letters and /subscriptions
+	// paths internally; mounting it under its own prefix (rather than
+	// registering those same paths again here) keeps it from shadowing the
+	// authenticated subscription API MakeHandler(svc, tracer) registers at
+	// "/" - a ServeMux prefers an exact match over a subtree match, so
+	// re-registering "/subscriptions" at the top level would silently
+	// capture every request to it with webhookapi's unauthenticated
+	// handler instead.
+	mux.Handle("/webhooks/", http.StripPrefix("/webhooks", webhookapi.MakeHandler(dead, subs, redeliver(notifier))))
+
+	if cfg.serverCert != "" || cfg.serverKey != "" {
+		logger.Info(fmt.Sprintf("Webhook notifier service started using https, cert %s key %s, exposed port %s", cfg.serverCert, cfg.serverKey, cfg.httpPort))
+		errs <- http.ListenAndServeTLS(p, cfg.serverCert, cfg.serverKey, mux)
+	} else {
+		logger.Info(fmt.Sprintf("Webhook notifier service started using http, exposed port %s", cfg.httpPort))
+		errs <- http.ListenAndServe(p, mux)
+	}
+}
+
+// redeliver builds a webhookapi.Replayer that re-POSTs a dead letter
+// straight to its original URL. It cannot go through notifier.Notify: that
+// resolves subscriptions by topic, and dl.SubscriptionID is not a topic.
+func redeliver(notifier webhook.Notifier) webhookapi.Replayer {
+	return func(dl webhook.DeadLetter) error {
+		return notifier.Redeliver(dl)
+	}
+}
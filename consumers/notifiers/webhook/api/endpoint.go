@@ -0,0 +1,94 @@
+// Copyright (c) Mainflux
+// SPDX-License-Identifier: Apache-2.0
+
+package api
+
+import (
+	"context"
+	"time"
+
+	"github.com/go-kit/kit/endpoint"
+	"github.com/mainflux/mainflux/consumers/notifiers/webhook"
+	"github.com/mainflux/mainflux/pkg/errors"
+)
+
+func listDeadLettersEndpoint(dead webhook.DeadLetterRepository) endpoint.Endpoint {
+	return func(ctx context.Context, request interface{}) (interface{}, error) {
+		req := request.(listRequest)
+
+		dls, err := dead.RetrieveAll(req.offset, req.limit)
+		if err != nil {
+			return nil, err
+		}
+
+		return deadLettersPageRes{
+			Offset:      req.offset,
+			Limit:       req.limit,
+			DeadLetters: dls,
+		}, nil
+	}
+}
+
+func replayDeadLetterEndpoint(dead webhook.DeadLetterRepository, replay Replayer) endpoint.Endpoint {
+	return func(ctx context.Context, request interface{}) (interface{}, error) {
+		req := request.(replayRequest)
+
+		if err := replayByID(dead, replay, req.id); err != nil {
+			return nil, err
+		}
+
+		return replayRes{Replayed: true}, nil
+	}
+}
+
+func createSubscriptionEndpoint(subs webhook.SubscriptionRepository) endpoint.Endpoint {
+	return func(ctx context.Context, request interface{}) (interface{}, error) {
+		req := request.(createSubscriptionRequest)
+		if err := req.validate(); err != nil {
+			return nil, err
+		}
+
+		sub := webhook.Subscription{
+			Topic:   req.Topic,
+			URL:     req.URL,
+			Secret:  req.Secret,
+			Headers: req.Headers,
+			Filter:  req.Filter,
+			Retry: webhook.RetryPolicy{
+				MaxAttempts: req.MaxAttempts,
+				BaseDelay:   time.Duration(req.BaseDelayMs) * time.Millisecond,
+				MaxDelay:    time.Duration(req.MaxDelayMs) * time.Millisecond,
+			},
+		}
+
+		id, err := subs.Save(sub)
+		if err != nil {
+			return nil, err
+		}
+
+		return createSubscriptionRes{ID: id}, nil
+	}
+}
+
+func replayByID(dead webhook.DeadLetterRepository, replay Replayer, id string) error {
+	const pageSize = 100
+	for offset := uint64(0); ; offset += pageSize {
+		dls, err := dead.RetrieveAll(offset, pageSize)
+		if err != nil {
+			return err
+		}
+		if len(dls) == 0 {
+			return errors.ErrNotFound
+		}
+
+		for _, dl := range dls {
+			if dl.ID != id {
+				continue
+			}
+			if err := replay(dl); err != nil {
+				return err
+			}
+			return dead.Remove(dl.ID)
+		}
+	}
+}
@@ -0,0 +1,47 @@
+// Copyright (c) Mainflux
+// SPDX-License-Identifier: Apache-2.0
+
+package api
+
+import (
+	"net/url"
+
+	"github.com/mainflux/mainflux/pkg/errors"
+)
+
+type listRequest struct {
+	offset uint64
+	limit  uint64
+}
+
+type replayRequest struct {
+	id string
+}
+
+type createSubscriptionRequest struct {
+	Topic   string            `json:"topic"`
+	URL     string            `json:"url"`
+	Secret  string            `json:"secret"`
+	Headers map[string]string `json:"headers"`
+
+	// Filter is a single equality/inequality comparison against a SenML
+	// record's name, e.g. "temperature==21" or "status!=0" - not a JSONPath
+	// or CEL expression. See webhook.matchesFilter for the exact grammar.
+	Filter      string `json:"filter"`
+	MaxAttempts int    `json:"max_attempts"`
+	BaseDelayMs int64  `json:"base_delay_ms"`
+	MaxDelayMs  int64  `json:"max_delay_ms"`
+}
+
+func (req createSubscriptionRequest) validate() error {
+	if req.Topic == "" || req.URL == "" {
+		return errors.ErrMalformedEntity
+	}
+
+	u, err := url.Parse(req.URL)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return errors.ErrMalformedEntity
+	}
+
+	return nil
+}
@@ -0,0 +1,31 @@
+// Copyright (c) Mainflux
+// SPDX-License-Identifier: Apache-2.0
+
+package api
+
+import (
+	"testing"
+
+	"github.com/mainflux/mainflux/pkg/errors"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCreateSubscriptionRequestValidate(t *testing.T) {
+	cases := []struct {
+		desc string
+		req  createSubscriptionRequest
+		err  error
+	}{
+		{desc: "valid https URL", req: createSubscriptionRequest{Topic: "channels.1", URL: "https://example.com/hook"}, err: nil},
+		{desc: "valid http URL", req: createSubscriptionRequest{Topic: "channels.1", URL: "http://example.com/hook"}, err: nil},
+		{desc: "missing topic", req: createSubscriptionRequest{URL: "https://example.com/hook"}, err: errors.ErrMalformedEntity},
+		{desc: "missing URL", req: createSubscriptionRequest{Topic: "channels.1"}, err: errors.ErrMalformedEntity},
+		{desc: "unsupported scheme", req: createSubscriptionRequest{Topic: "channels.1", URL: "file:///etc/passwd"}, err: errors.ErrMalformedEntity},
+		{desc: "no host", req: createSubscriptionRequest{Topic: "channels.1", URL: "https://"}, err: errors.ErrMalformedEntity},
+	}
+
+	for _, tc := range cases {
+		err := tc.req.validate()
+		assert.Equal(t, tc.err, err, tc.desc)
+	}
+}
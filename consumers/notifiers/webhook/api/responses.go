@@ -0,0 +1,20 @@
+// Copyright (c) Mainflux
+// SPDX-License-Identifier: Apache-2.0
+
+package api
+
+import "github.com/mainflux/mainflux/consumers/notifiers/webhook"
+
+type deadLettersPageRes struct {
+	Offset      uint64               `json:"offset"`
+	Limit       uint64               `json:"limit"`
+	DeadLetters []webhook.DeadLetter `json:"dead_letters"`
+}
+
+type replayRes struct {
+	Replayed bool `json:"replayed"`
+}
+
+type createSubscriptionRes struct {
+	ID string `json:"id"`
+}
@@ -0,0 +1,107 @@
+// Copyright (c) Mainflux
+// SPDX-License-Identifier: Apache-2.0
+
+// Package api exposes the webhook dead-letter store over HTTP so operators
+// can inspect and replay permanently failed deliveries.
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"strconv"
+
+	kithttp "github.com/go-kit/kit/transport/http"
+	"github.com/gorilla/mux"
+	"github.com/mainflux/mainflux/consumers/notifiers/webhook"
+	"github.com/mainflux/mainflux/pkg/errors"
+)
+
+// Replayer resends a previously failed delivery. The replay endpoint removes
+// the dead letter from the store once the Replayer succeeds.
+type Replayer func(dl webhook.DeadLetter) error
+
+// MakeHandler returns an HTTP handler exposing the dead-letter inspection
+// and replay endpoints under /dead-letters, and the subscription creation
+// endpoint under /subscriptions.
+func MakeHandler(dead webhook.DeadLetterRepository, subs webhook.SubscriptionRepository, replay Replayer) http.Handler {
+	opts := []kithttp.ServerOption{
+		kithttp.ServerErrorEncoder(encodeError),
+	}
+
+	r := mux.NewRouter()
+
+	r.Handle("/dead-letters", kithttp.NewServer(
+		listDeadLettersEndpoint(dead),
+		decodeListRequest,
+		encodeResponse,
+		opts...,
+	)).Methods(http.MethodGet)
+
+	r.Handle("/dead-letters/{id}/replay", kithttp.NewServer(
+		replayDeadLetterEndpoint(dead, replay),
+		decodeReplayRequest,
+		encodeResponse,
+		opts...,
+	)).Methods(http.MethodPost)
+
+	r.Handle("/subscriptions", kithttp.NewServer(
+		createSubscriptionEndpoint(subs),
+		decodeCreateSubscriptionRequest,
+		encodeResponse,
+		opts...,
+	)).Methods(http.MethodPost)
+
+	return r
+}
+
+func decodeListRequest(_ context.Context, r *http.Request) (interface{}, error) {
+	offset, err := readUint(r, "offset", 0)
+	if err != nil {
+		return nil, err
+	}
+	limit, err := readUint(r, "limit", 20)
+	if err != nil {
+		return nil, err
+	}
+
+	return listRequest{offset: offset, limit: limit}, nil
+}
+
+func decodeReplayRequest(_ context.Context, r *http.Request) (interface{}, error) {
+	return replayRequest{id: mux.Vars(r)["id"]}, nil
+}
+
+func decodeCreateSubscriptionRequest(_ context.Context, r *http.Request) (interface{}, error) {
+	var req createSubscriptionRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		return nil, errors.Wrap(errors.ErrMalformedEntity, err)
+	}
+	return req, nil
+}
+
+func readUint(r *http.Request, key string, def uint64) (uint64, error) {
+	v := r.URL.Query().Get(key)
+	if v == "" {
+		return def, nil
+	}
+	return strconv.ParseUint(v, 10, 64)
+}
+
+func encodeResponse(_ context.Context, w http.ResponseWriter, response interface{}) error {
+	w.Header().Set("Content-Type", "application/json")
+	return json.NewEncoder(w).Encode(response)
+}
+
+func encodeError(_ context.Context, err error, w http.ResponseWriter) {
+	switch {
+	case errors.Contains(err, errors.ErrNotFound):
+		w.WriteHeader(http.StatusNotFound)
+	case errors.Contains(err, errors.ErrMalformedEntity):
+		w.WriteHeader(http.StatusBadRequest)
+	default:
+		w.WriteHeader(http.StatusInternalServerError)
+	}
+
+	json.NewEncoder(w).Encode(map[string]string{"error": err.Error()})
+}
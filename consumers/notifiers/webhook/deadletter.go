@@ -0,0 +1,32 @@
+// Copyright (c) Mainflux
+// SPDX-License-Identifier: Apache-2.0
+
+package webhook
+
+import "time"
+
+// DeadLetter represents a webhook delivery that permanently failed after
+// exhausting its subscription's retry policy.
+type DeadLetter struct {
+	ID             string
+	SubscriptionID string
+	URL            string
+	Payload        []byte
+	Signature      string
+	Error          string
+	FailedAt       time.Time
+}
+
+// DeadLetterRepository persists permanently failed deliveries so operators
+// can inspect and replay them.
+type DeadLetterRepository interface {
+	// Save records a permanently failed delivery.
+	Save(dl DeadLetter) error
+
+	// RetrieveAll returns dead letters, most recent first, limited to
+	// limit entries starting at offset.
+	RetrieveAll(offset, limit uint64) ([]DeadLetter, error)
+
+	// Remove deletes a dead letter once it has been replayed.
+	Remove(id string) error
+}
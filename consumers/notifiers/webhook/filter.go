@@ -0,0 +1,74 @@
+// Copyright (c) Mainflux
+// SPDX-License-Identifier: Apache-2.0
+
+package webhook
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/mainflux/mainflux/pkg/messaging"
+)
+
+// matchesFilter reports whether msg should be delivered given the
+// subscription's filter expression. An empty filter matches everything.
+//
+// Only a small subset of equality expressions against a SenML record name
+// is supported, e.g. "temperature==21" or "status!=0". This is the single
+// extension point a full JSONPath/CEL engine would plug into without
+// touching the delivery pipeline above.
+//
+// msg.Payload is the wire-format SenML array pkg/transformers/senml decodes
+// (a JSON array of records with "name" and one of "value"/"stringValue"/
+// "boolValue"/"dataValue"), not an arbitrary JSON object - any other shape,
+// or an unrecognized filter expression, is treated as a non-match rather
+// than silently delivering every message a malformed filter can't evaluate.
+func matchesFilter(filter string, msg messaging.Message) bool {
+	if filter == "" {
+		return true
+	}
+
+	name, op, want, ok := parseFilter(filter)
+	if !ok {
+		return false
+	}
+
+	var records []map[string]interface{}
+	if err := json.Unmarshal(msg.Payload, &records); err != nil {
+		return false
+	}
+
+	match := false
+	for _, r := range records {
+		if r["name"] != name {
+			continue
+		}
+		match = fmt.Sprintf("%v", recordValue(r)) == want
+		break
+	}
+
+	if op == "!=" {
+		return !match
+	}
+	return match
+}
+
+func parseFilter(filter string) (name, op, want string, ok bool) {
+	for _, op := range []string{"==", "!="} {
+		if parts := strings.SplitN(filter, op, 2); len(parts) == 2 {
+			return strings.TrimSpace(parts[0]), op, strings.TrimSpace(parts[1]), true
+		}
+	}
+	return "", "", "", false
+}
+
+// recordValue returns whichever of a SenML record's value fields is set.
+func recordValue(r map[string]interface{}) interface{} {
+	for _, key := range []string{"value", "stringValue", "boolValue", "dataValue"} {
+		if v, ok := r[key]; ok {
+			return v
+		}
+	}
+	return nil
+}
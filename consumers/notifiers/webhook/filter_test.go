@@ -0,0 +1,32 @@
+// Copyright (c) Mainflux
+// SPDX-License-Identifier: Apache-2.0
+
+package webhook
+
+import (
+	"testing"
+
+	"github.com/mainflux/mainflux/pkg/messaging"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMatchesFilterEmptyFilterMatchesEverything(t *testing.T) {
+	msg := messaging.Message{Payload: []byte(`not even json`)}
+	assert.True(t, matchesFilter("", msg))
+}
+
+func TestMatchesFilterEvaluatesAgainstSenMLRecordsByName(t *testing.T) {
+	payload := []byte(`[{"name":"humidity","value":55},{"name":"temperature","value":21}]`)
+
+	assert.True(t, matchesFilter("temperature==21", messaging.Message{Payload: payload}), "a record named temperature with value 21 should match")
+	assert.False(t, matchesFilter("temperature==22", messaging.Message{Payload: payload}), "value mismatch should not match")
+	assert.True(t, matchesFilter("temperature!=22", messaging.Message{Payload: payload}), "!= should invert the comparison")
+	assert.False(t, matchesFilter("missing==1", messaging.Message{Payload: payload}), "a record name absent from the batch should not match")
+}
+
+func TestMatchesFilterFailsClosedOnUnparseableFilterOrPayload(t *testing.T) {
+	senmlPayload := []byte(`[{"name":"temperature","value":21}]`)
+
+	assert.False(t, matchesFilter("no operator here", messaging.Message{Payload: senmlPayload}), "an unrecognized filter expression must not fail open")
+	assert.False(t, matchesFilter("temperature==21", messaging.Message{Payload: []byte(`{"name":"temperature","value":21}`)}), "a non-array payload must not fail open")
+}
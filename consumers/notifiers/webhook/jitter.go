@@ -0,0 +1,12 @@
+// Copyright (c) Mainflux
+// SPDX-License-Identifier: Apache-2.0
+
+package webhook
+
+import "math/rand"
+
+// jitterFraction returns a pseudo-random value in [0, 1) used to spread out
+// retry delays across concurrently failing subscribers.
+func jitterFraction() float64 {
+	return rand.Float64()
+}
@@ -0,0 +1,51 @@
+// Copyright (c) Mainflux
+// SPDX-License-Identifier: Apache-2.0
+
+package webhook
+
+import stdprometheus "github.com/prometheus/client_golang/prometheus"
+
+// Metrics records the outcome of webhook deliveries.
+type Metrics interface {
+	IncSuccess()
+	IncFailure()
+	IncRetry()
+}
+
+type prometheusMetrics struct {
+	success stdprometheus.Counter
+	failure stdprometheus.Counter
+	retry   stdprometheus.Counter
+}
+
+// NewMetrics returns a Prometheus-backed Metrics implementation whose
+// counters are registered with the default Prometheus registry under the
+// "notifier_webhook" prefix.
+func NewMetrics() Metrics {
+	m := &prometheusMetrics{
+		success: stdprometheus.NewCounter(stdprometheus.CounterOpts{
+			Namespace: "notifier",
+			Subsystem: "webhook",
+			Name:      "delivery_success_total",
+			Help:      "Total number of successful webhook deliveries.",
+		}),
+		failure: stdprometheus.NewCounter(stdprometheus.CounterOpts{
+			Namespace: "notifier",
+			Subsystem: "webhook",
+			Name:      "delivery_failure_total",
+			Help:      "Total number of webhook deliveries that exhausted their retry policy.",
+		}),
+		retry: stdprometheus.NewCounter(stdprometheus.CounterOpts{
+			Namespace: "notifier",
+			Subsystem: "webhook",
+			Name:      "delivery_retry_total",
+			Help:      "Total number of webhook delivery retry attempts.",
+		}),
+	}
+	stdprometheus.MustRegister(m.success, m.failure, m.retry)
+	return m
+}
+
+func (m *prometheusMetrics) IncSuccess() { m.success.Inc() }
+func (m *prometheusMetrics) IncFailure() { m.failure.Inc() }
+func (m *prometheusMetrics) IncRetry()   { m.retry.Inc() }
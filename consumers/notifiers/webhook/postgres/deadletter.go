@@ -0,0 +1,124 @@
+// Copyright (c) Mainflux
+// SPDX-License-Identifier: Apache-2.0
+
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"time"
+
+	"github.com/gofrs/uuid"
+	"github.com/jmoiron/sqlx"
+	"github.com/mainflux/mainflux/consumers/notifiers/webhook"
+	"github.com/mainflux/mainflux/pkg/errors"
+)
+
+var _ webhook.DeadLetterRepository = (*deadLetterRepository)(nil)
+
+type deadLetterRepository struct {
+	db *sqlx.DB
+}
+
+// NewDeadLetterRepository returns a Postgres-backed
+// webhook.DeadLetterRepository.
+func NewDeadLetterRepository(db *sqlx.DB) webhook.DeadLetterRepository {
+	return &deadLetterRepository{db: db}
+}
+
+func (r *deadLetterRepository) Save(dl webhook.DeadLetter) error {
+	id, err := uuid.NewV4()
+	if err != nil {
+		return errors.Wrap(errors.ErrCreateEntity, err)
+	}
+
+	q := `INSERT INTO webhook_dead_letters (id, subscription_id, url, payload, signature, error, failed_at)
+	      VALUES (:id, :subscription_id, :url, :payload, :signature, :error, :failed_at)`
+
+	dbDL := toDBDeadLetter(dl)
+	dbDL.ID = id.String()
+
+	if _, err := r.db.NamedExecContext(context.Background(), q, dbDL); err != nil {
+		return errors.Wrap(errors.ErrCreateEntity, err)
+	}
+
+	return nil
+}
+
+func (r *deadLetterRepository) RetrieveAll(offset, limit uint64) ([]webhook.DeadLetter, error) {
+	q := `SELECT id, subscription_id, url, payload, signature, error, failed_at FROM webhook_dead_letters
+	      ORDER BY failed_at DESC LIMIT :limit OFFSET :offset`
+
+	rows, err := r.db.NamedQueryContext(context.Background(), q, map[string]interface{}{
+		"limit":  limit,
+		"offset": offset,
+	})
+	if err != nil {
+		return nil, errors.Wrap(errors.ErrViewEntity, err)
+	}
+	defer rows.Close()
+
+	var dls []webhook.DeadLetter
+	for rows.Next() {
+		var dbDL dbDeadLetter
+		if err := rows.StructScan(&dbDL); err != nil {
+			return nil, errors.Wrap(errors.ErrViewEntity, err)
+		}
+		dls = append(dls, toDeadLetter(dbDL))
+	}
+
+	return dls, nil
+}
+
+func (r *deadLetterRepository) Remove(id string) error {
+	q := `DELETE FROM webhook_dead_letters WHERE id = $1`
+
+	res, err := r.db.ExecContext(context.Background(), q, id)
+	if err != nil {
+		return errors.Wrap(errors.ErrRemoveEntity, err)
+	}
+
+	cnt, err := res.RowsAffected()
+	if err != nil {
+		return errors.Wrap(errors.ErrRemoveEntity, err)
+	}
+	if cnt == 0 {
+		return errors.Wrap(errors.ErrRemoveEntity, sql.ErrNoRows)
+	}
+
+	return nil
+}
+
+type dbDeadLetter struct {
+	ID             string `db:"id"`
+	SubscriptionID string `db:"subscription_id"`
+	URL            string `db:"url"`
+	Payload        []byte `db:"payload"`
+	Signature      string `db:"signature"`
+	Error          string `db:"error"`
+	FailedAt       int64  `db:"failed_at"`
+}
+
+func toDBDeadLetter(dl webhook.DeadLetter) dbDeadLetter {
+	return dbDeadLetter{
+		ID:             dl.ID,
+		SubscriptionID: dl.SubscriptionID,
+		URL:            dl.URL,
+		Payload:        dl.Payload,
+		Signature:      dl.Signature,
+		Error:          dl.Error,
+		FailedAt:       dl.FailedAt.UnixNano(),
+	}
+}
+
+func toDeadLetter(dbDL dbDeadLetter) webhook.DeadLetter {
+	return webhook.DeadLetter{
+		ID:             dbDL.ID,
+		SubscriptionID: dbDL.SubscriptionID,
+		URL:            dbDL.URL,
+		Payload:        dbDL.Payload,
+		Signature:      dbDL.Signature,
+		Error:          dbDL.Error,
+		FailedAt:       time.Unix(0, dbDL.FailedAt),
+	}
+}
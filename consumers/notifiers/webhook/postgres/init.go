@@ -0,0 +1,59 @@
+// Copyright (c) Mainflux
+// SPDX-License-Identifier: Apache-2.0
+
+package postgres
+
+import (
+	migrate "github.com/rubenv/sql-migrate"
+)
+
+// Migration returns the webhook notifier's schema migrations: the
+// subscription table keyed by channel/subtopic topic, and the dead-letter
+// store for permanently failed deliveries.
+func Migration() *migrate.MemoryMigrationSource {
+	return &migrate.MemoryMigrationSource{
+		Migrations: []*migrate.Migration{
+			{
+				Id: "webhook_1",
+				Up: []string{
+					`CREATE TABLE IF NOT EXISTS webhook_subscriptions (
+						id            UUID PRIMARY KEY,
+						topic         VARCHAR(1024) NOT NULL,
+						url           TEXT NOT NULL,
+						secret        TEXT,
+						headers       JSONB,
+						filter        TEXT,
+						max_attempts  INTEGER NOT NULL DEFAULT 0,
+						base_delay_ms BIGINT NOT NULL DEFAULT 0,
+						max_delay_ms  BIGINT NOT NULL DEFAULT 0
+					)`,
+					`CREATE INDEX IF NOT EXISTS webhook_subscriptions_topic_idx
+						ON webhook_subscriptions (topic)`,
+					`CREATE TABLE IF NOT EXISTS webhook_dead_letters (
+						id              UUID PRIMARY KEY,
+						subscription_id VARCHAR(254) NOT NULL,
+						url             TEXT NOT NULL,
+						payload         BYTEA,
+						error           TEXT,
+						failed_at       BIGINT NOT NULL
+					)`,
+					`CREATE INDEX IF NOT EXISTS webhook_dead_letters_subscription_id_idx
+						ON webhook_dead_letters (subscription_id)`,
+				},
+				Down: []string{
+					"DROP TABLE IF EXISTS webhook_dead_letters",
+					"DROP TABLE IF EXISTS webhook_subscriptions",
+				},
+			},
+			{
+				Id: "webhook_2",
+				Up: []string{
+					`ALTER TABLE webhook_dead_letters ADD COLUMN IF NOT EXISTS signature TEXT`,
+				},
+				Down: []string{
+					`ALTER TABLE webhook_dead_letters DROP COLUMN IF EXISTS signature`,
+				},
+			},
+		},
+	}
+}
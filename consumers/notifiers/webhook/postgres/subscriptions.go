@@ -0,0 +1,123 @@
+// Copyright (c) Mainflux
+// SPDX-License-Identifier: Apache-2.0
+
+package postgres
+
+import (
+	"context"
+	"encoding/json"
+	"time"
+
+	"github.com/gofrs/uuid"
+	"github.com/jmoiron/sqlx"
+	"github.com/mainflux/mainflux/consumers/notifiers/webhook"
+	"github.com/mainflux/mainflux/pkg/errors"
+)
+
+func durationMs(ms int64) time.Duration {
+	return time.Duration(ms) * time.Millisecond
+}
+
+var _ webhook.SubscriptionRepository = (*subscriptionRepository)(nil)
+
+type subscriptionRepository struct {
+	db *sqlx.DB
+}
+
+// NewSubscriptionRepository returns a Postgres-backed
+// webhook.SubscriptionRepository that resolves the webhook targets
+// registered against a channel/subtopic pair.
+func NewSubscriptionRepository(db *sqlx.DB) webhook.SubscriptionRepository {
+	return &subscriptionRepository{db: db}
+}
+
+func (r *subscriptionRepository) SubscriptionsFor(from string) ([]webhook.Subscription, error) {
+	q := `SELECT id, url, secret, headers, filter, max_attempts, base_delay_ms, max_delay_ms
+	      FROM webhook_subscriptions WHERE topic = $1`
+
+	rows, err := r.db.QueryxContext(context.Background(), q, from)
+	if err != nil {
+		return nil, errors.Wrap(errors.ErrViewEntity, err)
+	}
+	defer rows.Close()
+
+	var subs []webhook.Subscription
+	for rows.Next() {
+		var (
+			id, url, secret, filter string
+			headersJSON             []byte
+			maxAttempts             int
+			baseDelayMs, maxDelayMs int64
+		)
+		if err := rows.Scan(&id, &url, &secret, &headersJSON, &filter, &maxAttempts, &baseDelayMs, &maxDelayMs); err != nil {
+			return nil, errors.Wrap(errors.ErrViewEntity, err)
+		}
+
+		var headers map[string]string
+		if len(headersJSON) > 0 {
+			if err := json.Unmarshal(headersJSON, &headers); err != nil {
+				return nil, errors.Wrap(errors.ErrViewEntity, err)
+			}
+		}
+
+		subs = append(subs, webhook.Subscription{
+			ID:      id,
+			URL:     url,
+			Secret:  secret,
+			Headers: headers,
+			Filter:  filter,
+			Retry: webhook.RetryPolicy{
+				MaxAttempts: maxAttempts,
+				BaseDelay:   durationMs(baseDelayMs),
+				MaxDelay:    durationMs(maxDelayMs),
+			},
+		})
+	}
+
+	return subs, nil
+}
+
+func (r *subscriptionRepository) Save(sub webhook.Subscription) (string, error) {
+	id, err := uuid.NewV4()
+	if err != nil {
+		return "", errors.Wrap(errors.ErrCreateEntity, err)
+	}
+
+	headersJSON, err := json.Marshal(sub.Headers)
+	if err != nil {
+		return "", errors.Wrap(errors.ErrCreateEntity, err)
+	}
+
+	q := `INSERT INTO webhook_subscriptions (id, topic, url, secret, headers, filter, max_attempts, base_delay_ms, max_delay_ms)
+	      VALUES (:id, :topic, :url, :secret, :headers, :filter, :max_attempts, :base_delay_ms, :max_delay_ms)`
+
+	dbSub := dbSubscription{
+		ID:          id.String(),
+		Topic:       sub.Topic,
+		URL:         sub.URL,
+		Secret:      sub.Secret,
+		Headers:     headersJSON,
+		Filter:      sub.Filter,
+		MaxAttempts: sub.Retry.MaxAttempts,
+		BaseDelayMs: int64(sub.Retry.BaseDelay / time.Millisecond),
+		MaxDelayMs:  int64(sub.Retry.MaxDelay / time.Millisecond),
+	}
+
+	if _, err := r.db.NamedExecContext(context.Background(), q, dbSub); err != nil {
+		return "", errors.Wrap(errors.ErrCreateEntity, err)
+	}
+
+	return dbSub.ID, nil
+}
+
+type dbSubscription struct {
+	ID          string `db:"id"`
+	Topic       string `db:"topic"`
+	URL         string `db:"url"`
+	Secret      string `db:"secret"`
+	Headers     []byte `db:"headers"`
+	Filter      string `db:"filter"`
+	MaxAttempts int    `db:"max_attempts"`
+	BaseDelayMs int64  `db:"base_delay_ms"`
+	MaxDelayMs  int64  `db:"max_delay_ms"`
+}
@@ -0,0 +1,244 @@
+// Copyright (c) Mainflux
+// SPDX-License-Identifier: Apache-2.0
+
+// Package webhook provides a notifiers.Notifier implementation that
+// delivers messages to subscriber-supplied HTTP(S) endpoints.
+package webhook
+
+import (
+	"bytes"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/mainflux/mainflux/pkg/errors"
+	"github.com/mainflux/mainflux/pkg/messaging"
+)
+
+// ErrDeliveryFailed indicates that a message could not be delivered to the
+// subscriber's endpoint after exhausting the retry policy.
+var ErrDeliveryFailed = errors.New("webhook delivery failed")
+
+// RetryPolicy configures how many times, and how far apart, a failed
+// delivery is retried before it is considered permanently failed.
+type RetryPolicy struct {
+	MaxAttempts int
+	BaseDelay   time.Duration
+	MaxDelay    time.Duration
+}
+
+// Subscription describes a single webhook delivery target.
+type Subscription struct {
+	ID      string
+	Topic   string
+	URL     string
+	Secret  string
+	Headers map[string]string
+
+	// Filter restricts delivery to messages matching it; see matchesFilter
+	// for the (deliberately minimal, not JSONPath/CEL) expression grammar
+	// it accepts. Empty matches every message on Topic.
+	Filter string
+	Retry  RetryPolicy
+}
+
+// Config configures the webhook notifier.
+type Config struct {
+	Workers        int
+	RequestTimeout time.Duration
+	DefaultRetry   RetryPolicy
+}
+
+// DefaultConfig returns sane defaults for a webhook notifier.
+func DefaultConfig() Config {
+	return Config{
+		Workers:        10,
+		RequestTimeout: 5 * time.Second,
+		DefaultRetry: RetryPolicy{
+			MaxAttempts: 5,
+			BaseDelay:   500 * time.Millisecond,
+			MaxDelay:    30 * time.Second,
+		},
+	}
+}
+
+type notifier struct {
+	cfg     Config
+	client  *http.Client
+	subs    SubscriptionRepository
+	jobs    chan job
+	metrics Metrics
+	dead    DeadLetterRepository
+}
+
+type job struct {
+	sub     Subscription
+	payload []byte
+}
+
+// SubscriptionRepository resolves the webhook Subscription(s) that should
+// receive a message published "from" a given channel/subtopic, and
+// registers new ones against that same store.
+type SubscriptionRepository interface {
+	SubscriptionsFor(from string) ([]Subscription, error)
+
+	// Save persists a new subscription against sub.Topic and returns its
+	// generated ID.
+	Save(sub Subscription) (string, error)
+}
+
+// Notifier is notifiers.Notifier's Notify method plus Redeliver; it is
+// declared locally to avoid a cyclic import on the parent package.
+type Notifier interface {
+	Notify(from string, msg messaging.Message) error
+
+	// Redeliver resends a dead letter straight to its original URL, using
+	// the payload and signature recorded when delivery failed. It does not
+	// consult the SubscriptionRepository, since dl.SubscriptionID is not a
+	// topic and would never match a subscription there.
+	Redeliver(dl DeadLetter) error
+}
+
+// New creates a webhook notifier backed by subs and cfg. Deliveries that
+// exhaust their retry policy are recorded in dead so they can be inspected
+// and replayed later.
+func New(cfg Config, subs SubscriptionRepository, dead DeadLetterRepository, metrics Metrics) Notifier {
+	n := &notifier{
+		cfg:     cfg,
+		client:  &http.Client{Timeout: cfg.RequestTimeout},
+		subs:    subs,
+		jobs:    make(chan job, cfg.Workers*4),
+		metrics: metrics,
+		dead:    dead,
+	}
+
+	for i := 0; i < cfg.Workers; i++ {
+		go n.worker()
+	}
+
+	return n
+}
+
+func (n *notifier) Notify(from string, msg messaging.Message) error {
+	subs, err := n.subs.SubscriptionsFor(from)
+	if err != nil {
+		return err
+	}
+
+	for _, sub := range subs {
+		if !matchesFilter(sub.Filter, msg) {
+			continue
+		}
+		n.jobs <- job{sub: sub, payload: msg.Payload}
+	}
+
+	return nil
+}
+
+func (n *notifier) worker() {
+	for j := range n.jobs {
+		n.deliver(j)
+	}
+}
+
+func (n *notifier) deliver(j job) {
+	retry := j.sub.Retry
+	if retry.MaxAttempts == 0 {
+		retry = n.cfg.DefaultRetry
+	}
+
+	var lastErr error
+	for attempt := 1; attempt <= retry.MaxAttempts; attempt++ {
+		if err := n.send(j.sub, j.payload); err != nil {
+			lastErr = err
+			if attempt < retry.MaxAttempts {
+				n.metrics.IncRetry()
+				time.Sleep(backoff(retry, attempt))
+			}
+			continue
+		}
+
+		n.metrics.IncSuccess()
+		return
+	}
+
+	n.metrics.IncFailure()
+	if n.dead != nil {
+		var signature string
+		if j.sub.Secret != "" {
+			signature = sign(j.sub.Secret, j.payload)
+		}
+		_ = n.dead.Save(DeadLetter{
+			SubscriptionID: j.sub.ID,
+			URL:            j.sub.URL,
+			Payload:        j.payload,
+			Signature:      signature,
+			Error:          fmt.Sprintf("%s", lastErr),
+			FailedAt:       time.Now(),
+		})
+	}
+}
+
+func (n *notifier) send(sub Subscription, payload []byte) error {
+	var signature string
+	if sub.Secret != "" {
+		signature = sign(sub.Secret, payload)
+	}
+	return n.post(sub.URL, sub.ID, signature, sub.Headers, payload)
+}
+
+// Redeliver resends a dead letter straight to its original URL, bypassing
+// SubscriptionsFor entirely: dl.SubscriptionID identifies the subscription
+// the delivery came from, not the topic it was published on, so routing it
+// back through SubscriptionsFor would look up the wrong thing and silently
+// deliver nothing.
+func (n *notifier) Redeliver(dl DeadLetter) error {
+	return n.post(dl.URL, dl.SubscriptionID, dl.Signature, nil, dl.Payload)
+}
+
+func (n *notifier) post(url, deliveryID, signature string, headers map[string]string, payload []byte) error {
+	req, err := http.NewRequest(http.MethodPost, url, bytes.NewReader(payload))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/senml+json")
+	req.Header.Set("X-Mainflux-Delivery", deliveryID)
+	for k, v := range headers {
+		req.Header.Set(k, v)
+	}
+	if signature != "" {
+		req.Header.Set("X-Mainflux-Signature", signature)
+	}
+
+	res, err := n.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer res.Body.Close()
+
+	if res.StatusCode < http.StatusOK || res.StatusCode >= http.StatusMultipleChoices {
+		return errors.Wrap(ErrDeliveryFailed, fmt.Errorf("status code %d", res.StatusCode))
+	}
+
+	return nil
+}
+
+func sign(secret string, payload []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write(payload)
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+// backoff computes an exponentially increasing delay, capped at MaxDelay
+// and jittered by up to 20% to avoid thundering-herd retries.
+func backoff(r RetryPolicy, attempt int) time.Duration {
+	d := r.BaseDelay << uint(attempt-1)
+	if r.MaxDelay > 0 && d > r.MaxDelay {
+		d = r.MaxDelay
+	}
+	jitter := time.Duration(float64(d) * 0.2 * jitterFraction())
+	return d + jitter
+}
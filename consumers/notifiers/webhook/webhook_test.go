@@ -0,0 +1,190 @@
+// Copyright (c) Mainflux
+// SPDX-License-Identifier: Apache-2.0
+
+package webhook_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/mainflux/mainflux/consumers/notifiers/webhook"
+	"github.com/mainflux/mainflux/pkg/messaging"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type mockSubs struct {
+	subs []webhook.Subscription
+}
+
+func (m mockSubs) SubscriptionsFor(from string) ([]webhook.Subscription, error) {
+	return m.subs, nil
+}
+
+func (m mockSubs) Save(sub webhook.Subscription) (string, error) {
+	return sub.ID, nil
+}
+
+type mockDeadLetters struct {
+	mu    sync.Mutex
+	saved []webhook.DeadLetter
+}
+
+func (m *mockDeadLetters) Save(dl webhook.DeadLetter) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.saved = append(m.saved, dl)
+	return nil
+}
+
+func (m *mockDeadLetters) RetrieveAll(offset, limit uint64) ([]webhook.DeadLetter, error) {
+	return m.saved, nil
+}
+
+func (m *mockDeadLetters) Remove(id string) error {
+	return nil
+}
+
+type mockMetrics struct {
+	mu                      sync.Mutex
+	success, failure, retry int
+}
+
+func (m *mockMetrics) IncSuccess() { m.mu.Lock(); m.success++; m.mu.Unlock() }
+func (m *mockMetrics) IncFailure() { m.mu.Lock(); m.failure++; m.mu.Unlock() }
+func (m *mockMetrics) IncRetry()   { m.mu.Lock(); m.retry++; m.mu.Unlock() }
+
+func TestNotifyDeliversToSubscribedURL(t *testing.T) {
+	delivered := make(chan *http.Request, 1)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		delivered <- r
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer srv.Close()
+
+	subs := mockSubs{subs: []webhook.Subscription{{ID: "1", URL: srv.URL, Secret: "shh"}}}
+	dead := &mockDeadLetters{}
+	metrics := &mockMetrics{}
+	n := webhook.New(webhook.DefaultConfig(), subs, dead, metrics)
+
+	err := n.Notify("channels.1", messaging.Message{Payload: []byte(`{"value":1}`)})
+	require.Nil(t, err)
+
+	select {
+	case r := <-delivered:
+		assert.Equal(t, "1", r.Header.Get("X-Mainflux-Delivery"))
+		assert.NotEmpty(t, r.Header.Get("X-Mainflux-Signature"))
+	case <-time.After(time.Second):
+		t.Fatal("expected webhook delivery, got none")
+	}
+}
+
+func TestNotifyRecordsDeadLetterAfterRetriesExhausted(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusInternalServerError)
+	}))
+	defer srv.Close()
+
+	cfg := webhook.DefaultConfig()
+	cfg.DefaultRetry = webhook.RetryPolicy{MaxAttempts: 2, BaseDelay: time.Millisecond, MaxDelay: time.Millisecond}
+
+	subs := mockSubs{subs: []webhook.Subscription{{ID: "1", URL: srv.URL}}}
+	dead := &mockDeadLetters{}
+	metrics := &mockMetrics{}
+	n := webhook.New(cfg, subs, dead, metrics)
+
+	err := n.Notify("channels.1", messaging.Message{Payload: []byte(`{"value":1}`)})
+	require.Nil(t, err)
+
+	require.Eventually(t, func() bool {
+		dead.mu.Lock()
+		defer dead.mu.Unlock()
+		return len(dead.saved) == 1
+	}, time.Second, 10*time.Millisecond)
+}
+
+func TestRedeliverPostsDirectlyToDeadLetterURL(t *testing.T) {
+	delivered := make(chan *http.Request, 1)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		delivered <- r
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer srv.Close()
+
+	// A subscription store whose only subscription points at an unreachable
+	// URL so that, if Redeliver mistakenly routed through SubscriptionsFor,
+	// nothing would reach srv.
+	subs := mockSubs{subs: []webhook.Subscription{{ID: "1", URL: "http://unused.invalid"}}}
+	n := webhook.New(webhook.DefaultConfig(), subs, &mockDeadLetters{}, &mockMetrics{})
+
+	dl := webhook.DeadLetter{
+		ID:             "dl-1",
+		SubscriptionID: "sub-1",
+		URL:            srv.URL,
+		Payload:        []byte(`{"value":1}`),
+		Signature:      "deadbeef",
+	}
+	err := n.Redeliver(dl)
+	require.Nil(t, err)
+
+	select {
+	case r := <-delivered:
+		assert.Equal(t, "sub-1", r.Header.Get("X-Mainflux-Delivery"))
+		assert.Equal(t, "deadbeef", r.Header.Get("X-Mainflux-Signature"))
+	case <-time.After(time.Second):
+		t.Fatal("expected dead letter to be redelivered, got none")
+	}
+}
+
+func TestNotifyRecordsDeadLetterOn4xxResponse(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusUnprocessableEntity)
+	}))
+	defer srv.Close()
+
+	cfg := webhook.DefaultConfig()
+	cfg.DefaultRetry = webhook.RetryPolicy{MaxAttempts: 2, BaseDelay: time.Millisecond, MaxDelay: time.Millisecond}
+
+	subs := mockSubs{subs: []webhook.Subscription{{ID: "1", URL: srv.URL}}}
+	dead := &mockDeadLetters{}
+	metrics := &mockMetrics{}
+	n := webhook.New(cfg, subs, dead, metrics)
+
+	err := n.Notify("channels.1", messaging.Message{Payload: []byte(`{"value":1}`)})
+	require.Nil(t, err)
+
+	require.Eventually(t, func() bool {
+		dead.mu.Lock()
+		defer dead.mu.Unlock()
+		return len(dead.saved) == 1
+	}, time.Second, 10*time.Millisecond, "a rejected delivery can't recover on its own and must be dead-lettered like a 5xx")
+
+	metrics.mu.Lock()
+	defer metrics.mu.Unlock()
+	assert.Equal(t, 0, metrics.success, "a 4xx response must never be counted as a successful delivery")
+	assert.Equal(t, 1, metrics.failure)
+}
+
+func TestNotifySkipsSubscriptionsFilteredOut(t *testing.T) {
+	delivered := make(chan struct{}, 1)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		delivered <- struct{}{}
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer srv.Close()
+
+	subs := mockSubs{subs: []webhook.Subscription{{ID: "1", URL: srv.URL, Filter: "value==2"}}}
+	n := webhook.New(webhook.DefaultConfig(), subs, &mockDeadLetters{}, &mockMetrics{})
+
+	err := n.Notify("channels.1", messaging.Message{Payload: []byte(`[{"name":"value","value":1}]`)})
+	require.Nil(t, err)
+
+	select {
+	case <-delivered:
+		t.Fatal("expected filtered subscription not to receive the message")
+	case <-time.After(100 * time.Millisecond):
+	}
+}
@@ -0,0 +1,269 @@
+// Copyright (c) Mainflux
+// SPDX-License-Identifier: Apache-2.0
+
+package influxdb
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/influxdata/influxdb-client-go/v2/api/write"
+	"github.com/mainflux/mainflux/logger"
+	stdprometheus "github.com/prometheus/client_golang/prometheus"
+)
+
+// syncWriter is the subset of influxdb-client-go's api.WriteAPIBlocking the
+// batch writer depends on, declared locally so tests can substitute a fake
+// without reproducing the full third-party interface. Unlike the async
+// WriteAPI, a blocking write returns the error for exactly the lines just
+// written, so a flush's outcome never needs to be guessed at by matching it
+// to some later, unrelated error report.
+type syncWriter interface {
+	WriteRecord(ctx context.Context, line ...string) error
+}
+
+// BatchConfig bounds how points are accumulated before being flushed to
+// InfluxDB, and how many pending points the writer will buffer in memory
+// before applying backpressure to its caller.
+type BatchConfig struct {
+	BatchSize     int
+	FlushInterval time.Duration
+	QueueSize     int
+	SpoolPath     string
+}
+
+// DefaultBatchConfig returns sane defaults for BatchConfig.
+func DefaultBatchConfig() BatchConfig {
+	return BatchConfig{
+		BatchSize:     500,
+		FlushInterval: time.Second,
+		QueueSize:     5000,
+	}
+}
+
+// batchMetrics are the Prometheus collectors exposed by a batchWriter.
+type batchMetrics struct {
+	queueDepth   stdprometheus.Gauge
+	spoolSize    stdprometheus.Gauge
+	dropCount    stdprometheus.Counter
+	flushLatency stdprometheus.Histogram
+}
+
+func newBatchMetrics() batchMetrics {
+	return batchMetrics{
+		queueDepth: stdprometheus.NewGauge(stdprometheus.GaugeOpts{
+			Namespace: "influxdb_writer", Name: "queue_depth",
+			Help: "Number of points currently buffered awaiting flush.",
+		}),
+		spoolSize: stdprometheus.NewGauge(stdprometheus.GaugeOpts{
+			Namespace: "influxdb_writer", Name: "spool_size",
+			Help: "Number of batches currently spooled to disk.",
+		}),
+		dropCount: stdprometheus.NewCounter(stdprometheus.CounterOpts{
+			Namespace: "influxdb_writer", Name: "dropped_points_total",
+			Help: "Total number of points dropped because a flush failed and the batch could not be spooled.",
+		}),
+		flushLatency: stdprometheus.NewHistogram(stdprometheus.HistogramOpts{
+			Namespace: "influxdb_writer", Name: "flush_latency_seconds",
+			Help: "Latency of flushing a batch of points to InfluxDB.",
+		}),
+	}
+}
+
+// Metrics returns the Prometheus collectors this writer exposes, for
+// registration by the caller.
+func (w *batchWriter) Metrics() []stdprometheus.Collector {
+	return []stdprometheus.Collector{
+		w.metrics.queueDepth,
+		w.metrics.spoolSize,
+		w.metrics.dropCount,
+		w.metrics.flushLatency,
+	}
+}
+
+// batchWriter accumulates points into size-and-time bounded batches and
+// flushes them through InfluxDB's blocking WriteAPI, so every flush gets its
+// own definitive success/failure answer instead of one inferred from a
+// shared, unordered error stream. When InfluxDB is unavailable and a spool
+// is configured, a flushed batch is spooled to disk instead of being
+// dropped (with no spool it is dropped and counted); a background goroutine
+// drains the spool, in order, once writes succeed again. The in-memory
+// queue is bounded, so a slow/unavailable InfluxDB applies backpressure to
+// Consume callers rather than growing without bound.
+type batchWriter struct {
+	cfg      BatchConfig
+	writeAPI syncWriter
+	spool    *spool
+	metrics  batchMetrics
+	logger   logger.Logger
+
+	queue chan *write.Point
+
+	closeOnce sync.Once
+	done      chan struct{}
+}
+
+func newBatchWriter(writeAPI syncWriter, cfg BatchConfig, log logger.Logger) (*batchWriter, error) {
+	var sp *spool
+	if cfg.SpoolPath != "" {
+		var err error
+		sp, err = openSpool(cfg.SpoolPath)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	w := &batchWriter{
+		cfg:      cfg,
+		writeAPI: writeAPI,
+		spool:    sp,
+		metrics:  newBatchMetrics(),
+		logger:   log,
+		queue:    make(chan *write.Point, cfg.QueueSize),
+		done:     make(chan struct{}),
+	}
+
+	go w.run()
+	if sp != nil {
+		go w.drainSpool()
+	}
+
+	return w, nil
+}
+
+// Enqueue blocks until every point in pts has been queued, applying
+// backpressure to the caller (and transitively to the NATS consumer
+// dispatching messages) when InfluxDB can't keep up.
+func (w *batchWriter) Enqueue(pts []*write.Point) {
+	for _, p := range pts {
+		w.queue <- p
+		w.metrics.queueDepth.Set(float64(len(w.queue)))
+	}
+}
+
+func (w *batchWriter) run() {
+	ticker := time.NewTicker(w.cfg.FlushInterval)
+	defer ticker.Stop()
+
+	var batch []*write.Point
+	flush := func() {
+		if len(batch) == 0 {
+			return
+		}
+		w.flush(batch)
+		batch = nil
+	}
+
+	for {
+		select {
+		case p := <-w.queue:
+			batch = append(batch, p)
+			w.metrics.queueDepth.Set(float64(len(w.queue)))
+			if len(batch) >= w.cfg.BatchSize {
+				flush()
+			}
+		case <-ticker.C:
+			flush()
+		case <-w.done:
+			flush()
+			return
+		}
+	}
+}
+
+// flush writes batch synchronously and spools it if and only if the write
+// itself reports a failure for exactly these lines - never a guess based on
+// some other flush's error arriving around the same time. When no spool is
+// configured, a failed flush has nowhere to land and the batch is dropped;
+// that loss is still counted and logged so it isn't silent.
+func (w *batchWriter) flush(batch []*write.Point) {
+	start := time.Now()
+	defer func() { w.metrics.flushLatency.Observe(time.Since(start).Seconds()) }()
+
+	err := w.writeAPI.WriteRecord(context.Background(), linesOf(batch)...)
+	if err == nil {
+		return
+	}
+
+	if w.spool == nil {
+		w.metrics.dropCount.Add(float64(len(batch)))
+		w.logger.Error(fmt.Sprintf("Dropped %d points: InfluxDB write failed and no spool is configured: %s", len(batch), err))
+		return
+	}
+
+	w.spoolBatch(batch)
+}
+
+func (w *batchWriter) spoolBatch(batch []*write.Point) {
+	data, err := json.Marshal(linesOf(batch))
+	if err != nil {
+		w.metrics.dropCount.Add(float64(len(batch)))
+		w.logger.Error(fmt.Sprintf("Dropped %d points: failed to marshal batch for spooling: %s", len(batch), err))
+		return
+	}
+
+	if err := w.spool.Save(data); err != nil {
+		w.metrics.dropCount.Add(float64(len(batch)))
+		w.logger.Error(fmt.Sprintf("Dropped %d points: InfluxDB write failed and spooling to disk also failed: %s", len(batch), err))
+		return
+	}
+
+	w.metrics.spoolSize.Set(float64(w.spool.Size()))
+}
+
+// drainSpool replays spooled batches, oldest first, as long as InfluxDB
+// accepts writes. It stops retrying a batch as soon as one fails, so
+// batches are never replayed out of order.
+func (w *batchWriter) drainSpool() {
+	ticker := time.NewTicker(w.cfg.FlushInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-w.done:
+			return
+		case <-ticker.C:
+			for {
+				key, data, ok, err := w.spool.Oldest()
+				if err != nil || !ok {
+					break
+				}
+
+				var lines []string
+				if err := json.Unmarshal(data, &lines); err != nil {
+					w.spool.Remove(key)
+					continue
+				}
+
+				if err := w.writeAPI.WriteRecord(context.Background(), lines...); err != nil {
+					break
+				}
+
+				if err := w.spool.Remove(key); err != nil {
+					break
+				}
+				w.metrics.spoolSize.Set(float64(w.spool.Size()))
+			}
+		}
+	}
+}
+
+func linesOf(batch []*write.Point) []string {
+	lines := make([]string, len(batch))
+	for i, p := range batch {
+		lines[i] = write.PointToLineProtocol(p, time.Nanosecond)
+	}
+	return lines
+}
+
+func (w *batchWriter) Close() {
+	w.closeOnce.Do(func() {
+		close(w.done)
+		if w.spool != nil {
+			w.spool.Close()
+		}
+	})
+}
@@ -0,0 +1,173 @@
+// Copyright (c) Mainflux
+// SPDX-License-Identifier: Apache-2.0
+
+package influxdb
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/influxdata/influxdb-client-go/v2/api/write"
+	log "github.com/mainflux/mainflux/logger"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// fakeSyncWriter is a test double for syncWriter that can be switched
+// between failing and healthy, so tests can drive the batchWriter through
+// an InfluxDB outage and recovery without a real server. Because the real
+// blocking WriteAPI returns the error for exactly the lines it was given,
+// the fake does the same instead of reporting errors on a side channel.
+type fakeSyncWriter struct {
+	mu      sync.Mutex
+	failing bool
+	lines   []string
+}
+
+func (f *fakeSyncWriter) setFailing(failing bool) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.failing = failing
+}
+
+func (f *fakeSyncWriter) Lines() []string {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	out := make([]string, len(f.lines))
+	copy(out, f.lines)
+	return out
+}
+
+func (f *fakeSyncWriter) WriteRecord(_ context.Context, line ...string) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if f.failing {
+		return fmt.Errorf("influxdb unavailable")
+	}
+	f.lines = append(f.lines, line...)
+	return nil
+}
+
+func TestBatchWriterSpoolsDuringOutageAndReplaysInOrderOnRecovery(t *testing.T) {
+	fake := &fakeSyncWriter{}
+	fake.setFailing(true)
+
+	cfg := BatchConfig{
+		BatchSize:     1,
+		FlushInterval: 10 * time.Millisecond,
+		QueueSize:     10,
+		SpoolPath:     filepath.Join(t.TempDir(), "spool.db"),
+	}
+
+	logger, _ := log.New(os.Stdout, log.Info.String())
+	bw, err := newBatchWriter(fake, cfg, logger)
+	require.Nil(t, err, "creating the batch writer should succeed")
+	defer bw.Close()
+
+	const n = 3
+	var pts []*write.Point
+	for i := 0; i < n; i++ {
+		pts = append(pts, write.NewPoint(
+			"m",
+			map[string]string{"seq": fmt.Sprintf("%d", i)},
+			map[string]interface{}{"value": i},
+			time.Unix(int64(i), 0),
+		))
+	}
+
+	for _, p := range pts {
+		bw.Enqueue([]*write.Point{p})
+	}
+
+	require.Eventually(t, func() bool {
+		return bw.spool.Size() == n
+	}, time.Second, 10*time.Millisecond, "every batch that fails to flush must be spooled rather than dropped")
+
+	fake.setFailing(false)
+
+	require.Eventually(t, func() bool {
+		return bw.spool.Size() == 0
+	}, time.Second, 10*time.Millisecond, "the spool must drain once InfluxDB recovers")
+
+	lines := fake.Lines()
+	require.Len(t, lines, n, "no message should be lost across the outage")
+	for i, line := range lines {
+		assert.Contains(t, line, fmt.Sprintf("seq=%d", i), "spooled batches must replay in the order they were spooled")
+	}
+}
+
+// TestBatchWriterDoesNotConfuseAnUnrelatedLaterFailureWithAnEarlierSuccess
+// guards against the bug a FIFO-order error-matching scheme is prone to: a
+// batch that flushes successfully must stay flushed even while a later,
+// unrelated batch is failing and being spooled.
+func TestBatchWriterDoesNotConfuseAnUnrelatedLaterFailureWithAnEarlierSuccess(t *testing.T) {
+	fake := &fakeSyncWriter{}
+
+	cfg := BatchConfig{
+		BatchSize:     1,
+		FlushInterval: 10 * time.Millisecond,
+		QueueSize:     10,
+		SpoolPath:     filepath.Join(t.TempDir(), "spool.db"),
+	}
+
+	logger, _ := log.New(os.Stdout, log.Info.String())
+	bw, err := newBatchWriter(fake, cfg, logger)
+	require.Nil(t, err)
+	defer bw.Close()
+
+	good := write.NewPoint("m", map[string]string{"seq": "good"}, map[string]interface{}{"value": 1}, time.Unix(1, 0))
+	bw.Enqueue([]*write.Point{good})
+
+	require.Eventually(t, func() bool {
+		return len(fake.Lines()) == 1
+	}, time.Second, 10*time.Millisecond, "the first batch must succeed while the writer is healthy")
+
+	fake.setFailing(true)
+	bad := write.NewPoint("m", map[string]string{"seq": "bad"}, map[string]interface{}{"value": 2}, time.Unix(2, 0))
+	bw.Enqueue([]*write.Point{bad})
+
+	require.Eventually(t, func() bool {
+		return bw.spool.Size() == 1
+	}, time.Second, 10*time.Millisecond, "only the failing batch must be spooled")
+
+	lines := fake.Lines()
+	require.Len(t, lines, 1, "the earlier successful batch must not be re-sent")
+	assert.True(t, strings.Contains(lines[0], "seq=good"))
+}
+
+// TestBatchWriterCountsDropsWhenNoSpoolIsConfigured guards against losing a
+// failed flush silently when the operator has opted out of spooling
+// (SpoolPath == ""): the batch has nowhere to go but dropped_points_total
+// still has to move, so the loss is visible.
+func TestBatchWriterCountsDropsWhenNoSpoolIsConfigured(t *testing.T) {
+	fake := &fakeSyncWriter{}
+	fake.setFailing(true)
+
+	cfg := BatchConfig{
+		BatchSize:     1,
+		FlushInterval: 10 * time.Millisecond,
+		QueueSize:     10,
+	}
+
+	logger, _ := log.New(os.Stdout, log.Info.String())
+	bw, err := newBatchWriter(fake, cfg, logger)
+	require.Nil(t, err)
+	defer bw.Close()
+	require.Nil(t, bw.spool, "no SpoolPath means no spool should be opened")
+
+	bw.Enqueue([]*write.Point{write.NewPoint("m", map[string]string{"seq": "0"}, map[string]interface{}{"value": 0}, time.Unix(0, 0))})
+	bw.Enqueue([]*write.Point{write.NewPoint("m", map[string]string{"seq": "1"}, map[string]interface{}{"value": 1}, time.Unix(1, 0))})
+
+	require.Eventually(t, func() bool {
+		return testutil.ToFloat64(bw.metrics.dropCount) == 2
+	}, time.Second, 10*time.Millisecond, "a flush that fails with no spool configured must still count its points as dropped")
+
+	assert.Empty(t, fake.Lines(), "a batch that failed to flush must never be reported as written")
+}
@@ -13,6 +13,7 @@ import (
 	"github.com/gofrs/uuid"
 	influxdata "github.com/influxdata/influxdb-client-go/v2"
 	writer "github.com/mainflux/mainflux/consumers/writers/influxdb"
+	"github.com/mainflux/mainflux/consumers/writers/influxdb/pipeline"
 	log "github.com/mainflux/mainflux/logger"
 	"github.com/mainflux/mainflux/pkg/errors"
 	"github.com/mainflux/mainflux/pkg/transformers/json"
@@ -70,9 +71,20 @@ func cleanDB() error {
 	return err
 }
 
+// testBatchConfig flushes almost immediately so assertions that query
+// InfluxDB right after Consume don't need to sleep for the production
+// default flush interval.
+func testBatchConfig() writer.BatchConfig {
+	cfg := writer.DefaultBatchConfig()
+	cfg.BatchSize = 1
+	cfg.FlushInterval = 10 * time.Millisecond
+	return cfg
+}
+
 func TestSaveSenml(t *testing.T) {
 	logger, _ := log.New(os.Stdout, log.Info.String())
-	repo := writer.New(client, testOrg, testBucket, testMainfluxToken, testMainfluxUrl, logger)
+	repo, err := writer.New(client, testOrg, testBucket, testMainfluxToken, testMainfluxUrl, nil, testBatchConfig(), logger)
+	require.Nil(t, err, "creating the InfluxDB writer should succeed")
 
 	cases := []struct {
 		desc         string
@@ -131,17 +143,19 @@ func TestSaveSenml(t *testing.T) {
 		err = repo.Consume(msgs)
 		assert.Nil(t, err, fmt.Sprintf("Save operation expected to succeed: %s.\n", err))
 
-		row, err := queryDB(selectMsgs)
-		assert.Nil(t, err, fmt.Sprintf("Querying InfluxDB to retrieve data expected to succeed: %s.\n", err))
-
-		count := len(row)
-		assert.Equal(t, tc.expectedSize, count, fmt.Sprintf("Expected to have %d messages saved, found %d instead.\n", tc.expectedSize, count))
+		// Consume only enqueues points; the batch writer flushes
+		// asynchronously, so give it a moment before querying.
+		require.Eventually(t, func() bool {
+			row, err := queryDB(selectMsgs)
+			return err == nil && len(row) == tc.expectedSize
+		}, time.Second, 10*time.Millisecond, fmt.Sprintf("expected to have %d messages saved", tc.expectedSize))
 	}
 }
 
 func TestSaveJSON(t *testing.T) {
 	logger, _ := log.New(os.Stdout, log.Info.String())
-	repo := writer.New(client, testOrg, testBucket, testMainfluxToken, testMainfluxUrl, logger)
+	repo, err := writer.New(client, testOrg, testBucket, testMainfluxToken, testMainfluxUrl, nil, testBatchConfig(), logger)
+	require.Nil(t, err, "creating the InfluxDB writer should succeed")
 
 	chid, err := uuid.NewV4()
 	require.Nil(t, err, fmt.Sprintf("got unexpected error: %s", err))
@@ -236,10 +250,61 @@ func TestSaveJSON(t *testing.T) {
 		err = repo.Consume(tc.msgs)
 		assert.True(t, errors.Contains(err, tc.err), fmt.Sprintf("%s expected %s, got %s", tc.desc, tc.err, err))
 
-		row, err := queryDB(selectMsgs)
-		assert.Nil(t, err, fmt.Sprintf("Querying InfluxDB to retrieve data expected to succeed: %s.\n", err))
+		require.Eventually(t, func() bool {
+			row, err := queryDB(selectMsgs)
+			return err == nil && len(row) == streamsSize
+		}, time.Second, 10*time.Millisecond, fmt.Sprintf("expected to have %d messages saved", streamsSize))
+	}
+}
+
+// recordingStage appends every batch it sees, in the order it was called,
+// letting the test below assert the pipeline preserves record order and
+// reshapes batches the same way on a retried Consume call.
+type recordingStage struct {
+	seen [][]pipeline.Record
+}
+
+func (r *recordingStage) Transform(batch []pipeline.Record) ([]pipeline.Record, error) {
+	cp := make([]pipeline.Record, len(batch))
+	copy(cp, batch)
+	r.seen = append(r.seen, cp)
+	return batch, nil
+}
+
+func TestPipelinePreservesOrderingAndIsIdempotentOnRetry(t *testing.T) {
+	logger, _ := log.New(os.Stdout, log.Info.String())
+	stage := &recordingStage{}
+	repo, err := writer.New(client, testOrg, testBucket, testMainfluxToken, testMainfluxUrl, []pipeline.Transformer{stage}, testBatchConfig(), logger)
+	require.Nil(t, err, "creating the InfluxDB writer should succeed")
+
+	err = cleanDB()
+	require.Nil(t, err, fmt.Sprintf("Cleaning data from InfluxDB expected to succeed: %s.\n", err))
 
-		count := len(row)
-		assert.Equal(t, streamsSize, count, fmt.Sprintf("Expected to have %d messages saved, found %d instead.\n", streamsSize, count))
+	now := time.Now().UnixNano()
+	var msgs []senml.Message
+	for i := 0; i < valueFields; i++ {
+		val := float64(i)
+		msgs = append(msgs, senml.Message{
+			Channel:   "45",
+			Publisher: "2580",
+			Protocol:  "http",
+			Name:      fmt.Sprintf("sensor-%d", i),
+			Value:     &val,
+			Time:      float64(now)/float64(1e9) - float64(i),
+		})
+	}
+
+	err = repo.Consume(msgs)
+	require.Nil(t, err, fmt.Sprintf("Save operation expected to succeed: %s.\n", err))
+
+	err = repo.Consume(msgs)
+	require.Nil(t, err, fmt.Sprintf("Retried save operation expected to succeed: %s.\n", err))
+
+	require.Len(t, stage.seen, 2, "expected the pipeline stage to run once per Consume call")
+	for _, batch := range stage.seen {
+		for i, r := range batch {
+			assert.Equal(t, msgs[i].Name, r.Name, "pipeline must preserve record ordering within a batch")
+		}
 	}
+	assert.Equal(t, stage.seen[0], stage.seen[1], "retrying the same batch must reshape it identically")
 }
@@ -0,0 +1,220 @@
+// Copyright (c) Mainflux
+// SPDX-License-Identifier: Apache-2.0
+
+// Package influxdb contains the domain concept definitions needed to
+// support Mainflux InfluxDB writer.
+package influxdb
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	influxdata "github.com/influxdata/influxdb-client-go/v2"
+	"github.com/influxdata/influxdb-client-go/v2/api/write"
+	"github.com/mainflux/mainflux/consumers"
+	"github.com/mainflux/mainflux/consumers/writers/influxdb/pipeline"
+	"github.com/mainflux/mainflux/logger"
+	"github.com/mainflux/mainflux/pkg/errors"
+	"github.com/mainflux/mainflux/pkg/transformers/json"
+	"github.com/mainflux/mainflux/pkg/transformers/senml"
+	stdprometheus "github.com/prometheus/client_golang/prometheus"
+)
+
+const (
+	senmlPoints = "messages"
+	jsonPoints  = "json"
+)
+
+var (
+	errSaveMessage = errors.New("failed to save message to influxdb database")
+	errMessageType = errors.New("invalid message type")
+
+	_ consumers.Consumer = (*influxRepo)(nil)
+)
+
+type influxRepo struct {
+	client   influxdata.Client
+	org      string
+	bucket   string
+	batch    *batchWriter
+	pipeline pipeline.Pipeline
+	logger   logger.Logger
+}
+
+// New returns a new InfluxDB writer, consuming the configured pipeline of
+// transformer stages before every batch of messages is written. Points are
+// accumulated and flushed according to batchCfg; when batchCfg.SpoolPath is
+// set, batches that fail to flush are spooled to disk and replayed once
+// InfluxDB recovers instead of being dropped.
+func New(client influxdata.Client, org, bucket, token, url string, stages []pipeline.Transformer, batchCfg BatchConfig, log logger.Logger) (consumers.Consumer, error) {
+	bw, err := newBatchWriter(client.WriteAPIBlocking(org, bucket), batchCfg, log)
+	if err != nil {
+		return nil, errors.Wrap(errSaveMessage, err)
+	}
+
+	return &influxRepo{
+		client:   client,
+		org:      org,
+		bucket:   bucket,
+		batch:    bw,
+		pipeline: pipeline.New(stages),
+		logger:   log,
+	}, nil
+}
+
+// Metrics returns the Prometheus collectors exposed by the writer's batch
+// writer, for registration by the caller.
+func (repo *influxRepo) Metrics() []stdprometheus.Collector {
+	return repo.batch.Metrics()
+}
+
+func (repo *influxRepo) Consume(message interface{}) error {
+	var pts []*write.Point
+	var err error
+
+	switch m := message.(type) {
+	case json.Messages:
+		pts, err = repo.jsonPoints(m)
+	case []senml.Message:
+		pts, err = repo.senmlPoints(m)
+	default:
+		return errMessageType
+	}
+	if err != nil {
+		return err
+	}
+
+	repo.batch.Enqueue(pts)
+
+	return nil
+}
+
+func (repo *influxRepo) senmlPoints(messages []senml.Message) ([]*write.Point, error) {
+	batch, err := repo.pipeline.Run(toRecords(messages))
+	if err != nil {
+		return nil, err
+	}
+
+	pts := make([]*write.Point, 0, len(batch))
+	for _, r := range batch {
+		tags := map[string]string{
+			"channel":   r.Channel,
+			"subtopic":  r.Subtopic,
+			"publisher": r.Publisher,
+			"protocol":  r.Protocol,
+			"name":      r.Name,
+		}
+		for k, v := range r.Tags {
+			if _, reserved := tags[k]; !reserved {
+				tags[k] = v
+			}
+		}
+		fields := map[string]interface{}{"value": r.Value}
+		switch {
+		case r.StringValue != nil:
+			fields["stringValue"] = *r.StringValue
+		case r.BoolValue != nil:
+			fields["boolValue"] = *r.BoolValue
+		case r.DataValue != nil:
+			fields["dataValue"] = *r.DataValue
+		}
+
+		pts = append(pts, write.NewPoint(senmlPoints, tags, fields, timestamp(r.Time)))
+	}
+
+	return pts, nil
+}
+
+func (repo *influxRepo) jsonPoints(msgs json.Messages) ([]*write.Point, error) {
+	pts := make([]*write.Point, 0, len(msgs.Data))
+	for _, m := range msgs.Data {
+		tags := map[string]string{
+			"channel":   m.Channel,
+			"subtopic":  m.Subtopic,
+			"publisher": m.Publisher,
+			"protocol":  m.Protocol,
+		}
+
+		flat := make(map[string]interface{})
+		if err := flattenJSON("", m.Payload, flat); err != nil {
+			return nil, err
+		}
+
+		pts = append(pts, write.NewPoint(fmt.Sprintf("%s.%s", jsonPoints, msgs.Format), tags, flat, time.Unix(0, m.Created)))
+	}
+
+	return pts, nil
+}
+
+// flattenJSON walks a nested JSON payload, writing every leaf value into
+// out keyed by its dot-joined path. Keys containing "/" are rejected, as is
+// the reserved "publisher" tag name, both of which would otherwise collide
+// with InfluxDB line-protocol syntax or Mainflux's own tags.
+func flattenJSON(prefix string, payload map[string]interface{}, out map[string]interface{}) error {
+	for k, v := range payload {
+		if strings.Contains(k, "/") || k == "publisher" {
+			return json.ErrInvalidKey
+		}
+
+		key := k
+		if prefix != "" {
+			key = prefix + "." + k
+		}
+
+		switch val := v.(type) {
+		case map[string]interface{}:
+			if err := flattenJSON(key, val, out); err != nil {
+				return err
+			}
+		default:
+			out[key] = val
+		}
+	}
+
+	return nil
+}
+
+func toRecords(messages []senml.Message) []pipeline.Record {
+	recs := make([]pipeline.Record, len(messages))
+	for i, m := range messages {
+		recs[i] = pipeline.Record{
+			Channel:     m.Channel,
+			Subtopic:    m.Subtopic,
+			Publisher:   m.Publisher,
+			Protocol:    m.Protocol,
+			Name:        m.Name,
+			Unit:        m.Unit,
+			Value:       senmlValue(m),
+			StringValue: m.StringValue,
+			BoolValue:   m.BoolValue,
+			DataValue:   m.DataValue,
+			Time:        m.Time,
+		}
+	}
+	return recs
+}
+
+// senmlValue returns the numeric reading for a record, for numeric pipeline
+// stages (deadband, downsample, unit_conversion) to operate on. It is not
+// the only value persisted: toRecords carries String/Bool/DataValue through
+// separately so non-numeric readings reach the writer intact.
+func senmlValue(m senml.Message) float64 {
+	switch {
+	case m.Value != nil:
+		return *m.Value
+	case m.Sum != nil:
+		return *m.Sum
+	case m.BoolValue != nil:
+		if *m.BoolValue {
+			return 1
+		}
+		return 0
+	default:
+		return 0
+	}
+}
+
+func timestamp(t float64) time.Time {
+	return time.Unix(0, int64(t*float64(time.Second)))
+}
@@ -0,0 +1,114 @@
+// Copyright (c) Mainflux
+// SPDX-License-Identifier: Apache-2.0
+
+package pipeline
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/BurntSushi/toml"
+)
+
+// ErrNoMetadataFetcher is returned by Build when the config declares an
+// "enrich" stage but the caller passed a nil MetadataFetcher. An enricher
+// with no fetcher would panic on the first message, so Build rejects the
+// config outright instead of constructing a stage that cannot work.
+var ErrNoMetadataFetcher = errors.New("pipeline: enrich stage configured without a MetadataFetcher")
+
+// ErrUnknownStageType is returned by Build when a StageConfig's Type
+// doesn't match any of the stages this package knows how to build, e.g. a
+// typo in the TOML config. Without this, a misspelled stage type would
+// silently vanish from the pipeline instead of failing to start.
+var ErrUnknownStageType = errors.New("pipeline: unknown stage type")
+
+// StageConfig declares one pipeline stage as read from the writer's
+// MF_*_WRITER_CONFIG_PATH TOML file. Only the fields relevant to Type are
+// populated; the rest are left at their zero value.
+type StageConfig struct {
+	Type string `toml:"type"`
+
+	// unit_conversion
+	Rules []UnitConversionRule `toml:"rules"`
+
+	// downsample
+	Samples int    `toml:"samples"`
+	Window  string `toml:"window"`
+	Fn      string `toml:"fn"`
+
+	// deadband
+	Threshold float64 `toml:"threshold"`
+
+	// enrich
+	CacheTTL string `toml:"cache_ttl"`
+
+	// retag
+	RenameName map[string]string `toml:"rename_name"`
+	DropUnit   []string          `toml:"drop_unit"`
+}
+
+// Config is the `[[pipeline]]` section of a writer's TOML configuration.
+type Config struct {
+	Stages []StageConfig `toml:"pipeline"`
+}
+
+// LoadConfig parses the pipeline section of the TOML file at path.
+func LoadConfig(path string) (Config, error) {
+	var cfg Config
+	if _, err := toml.DecodeFile(path, &cfg); err != nil {
+		return Config{}, err
+	}
+	return cfg, nil
+}
+
+// Build turns a parsed Config into the ordered Transformer slice writer.New
+// expects. Stages referencing a MetadataFetcher (enrich) must be supplied
+// by the caller, since the fetcher needs a live gRPC connection.
+func Build(cfg Config, fetch MetadataFetcher) ([]Transformer, error) {
+	stages := make([]Transformer, 0, len(cfg.Stages))
+
+	for _, s := range cfg.Stages {
+		switch s.Type {
+		case "unit_conversion":
+			stages = append(stages, NewUnitConverter(s.Rules))
+		case "downsample":
+			window, err := parseDuration(s.Window)
+			if err != nil {
+				return nil, err
+			}
+			stages = append(stages, NewDownsampler(DownsampleConfig{
+				Samples: s.Samples,
+				Window:  window.Seconds(),
+				Fn:      AggregateFn(s.Fn),
+			}))
+		case "deadband":
+			stages = append(stages, NewDeadband(s.Threshold))
+		case "enrich":
+			if fetch == nil {
+				return nil, ErrNoMetadataFetcher
+			}
+			ttl, err := parseDuration(s.CacheTTL)
+			if err != nil {
+				return nil, err
+			}
+			stages = append(stages, NewEnricher(fetch, ttl))
+		case "retag":
+			stages = append(stages, NewRetagger(RetagConfig{
+				RenameName: s.RenameName,
+				DropUnit:   s.DropUnit,
+			}))
+		default:
+			return nil, fmt.Errorf("%w: %q", ErrUnknownStageType, s.Type)
+		}
+	}
+
+	return stages, nil
+}
+
+func parseDuration(s string) (time.Duration, error) {
+	if s == "" {
+		return 0, nil
+	}
+	return time.ParseDuration(s)
+}
@@ -0,0 +1,20 @@
+// Copyright (c) Mainflux
+// SPDX-License-Identifier: Apache-2.0
+
+package pipeline_test
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/mainflux/mainflux/consumers/writers/influxdb/pipeline"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestBuildRejectsUnknownStageType(t *testing.T) {
+	cfg := pipeline.Config{Stages: []pipeline.StageConfig{{Type: "deadbnd"}}}
+
+	stages, err := pipeline.Build(cfg, nil)
+	assert.Nil(t, stages)
+	assert.True(t, errors.Is(err, pipeline.ErrUnknownStageType), "a misspelled stage type must fail to build, not silently vanish")
+}
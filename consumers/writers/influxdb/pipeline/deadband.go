@@ -0,0 +1,85 @@
+// Copyright (c) Mainflux
+// SPDX-License-Identifier: Apache-2.0
+
+package pipeline
+
+import "math"
+
+// historyPerSeries bounds how many recent per-sample decisions are
+// remembered for a single channel+name series, so every sample in a
+// redelivered batch - not just the last one - can be matched back to its
+// own original decision, without the history growing without bound. It
+// must be at least as large as the most samples a single series can see in
+// one retried batch, or an older sample that's aged out of history is
+// merely re-evaluated fresh (safe, just no longer guaranteed idempotent for
+// that one sample).
+const historyPerSeries = 256
+
+// decision is the outcome recorded for one sample of a series, keyed by its
+// SenML time, so a retry of that exact sample can replay it.
+type decision struct {
+	time float64
+	kept bool
+}
+
+// deadbandState is a series' dedupe history plus the value future samples
+// are compared against: the value of the most recently kept sample.
+type deadbandState struct {
+	value   float64
+	history []decision
+}
+
+func (s deadbandState) decisionFor(t float64) (kept, found bool) {
+	for _, d := range s.history {
+		if d.time == t {
+			return d.kept, true
+		}
+	}
+	return false, false
+}
+
+type deadband struct {
+	threshold float64
+	last      map[string]deadbandState
+}
+
+// NewDeadband returns a Transformer that drops samples whose value has
+// moved by less than threshold since the last value written for the same
+// channel+name series.
+func NewDeadband(threshold float64) Transformer {
+	return &deadband{threshold: threshold, last: make(map[string]deadbandState)}
+}
+
+func (d *deadband) Transform(batch []Record) ([]Record, error) {
+	out := make([]Record, 0, len(batch))
+	for _, r := range batch {
+		k := r.Channel + "." + r.Name
+		state, ok := d.last[k]
+
+		if kept, found := state.decisionFor(r.Time); found {
+			// A redelivered retry of a sample we've already decided on.
+			// Replay the original decision rather than re-evaluating
+			// against state that may have moved on in the meantime, so a
+			// retry can never be dropped (or kept) for a reason the
+			// original delivery wasn't.
+			if kept {
+				out = append(out, r)
+			}
+			continue
+		}
+
+		kept := !ok || math.Abs(r.Value-state.value) >= d.threshold
+		if kept {
+			state.value = r.Value
+			out = append(out, r)
+		}
+
+		state.history = append(state.history, decision{time: r.Time, kept: kept})
+		if len(state.history) > historyPerSeries {
+			state.history = state.history[len(state.history)-historyPerSeries:]
+		}
+		d.last[k] = state
+	}
+
+	return out, nil
+}
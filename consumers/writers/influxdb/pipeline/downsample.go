@@ -0,0 +1,129 @@
+// Copyright (c) Mainflux
+// SPDX-License-Identifier: Apache-2.0
+
+package pipeline
+
+import "sort"
+
+// AggregateFn is a rolling aggregation applied to a window of samples.
+type AggregateFn string
+
+const (
+	// AggregateMean replaces a window with the mean of its values.
+	AggregateMean AggregateFn = "mean"
+	// AggregateMin replaces a window with its minimum value.
+	AggregateMin AggregateFn = "min"
+	// AggregateMax replaces a window with its maximum value.
+	AggregateMax AggregateFn = "max"
+	// AggregateCount replaces a window with the number of samples in it.
+	AggregateCount AggregateFn = "count"
+)
+
+// DownsampleConfig configures windowing for the downsampling stage. A
+// window closes, and is emitted, once it holds Samples records or spans
+// Window seconds of SenML time, whichever comes first. A zero Samples or
+// Window disables that bound.
+type DownsampleConfig struct {
+	Samples int
+	Window  float64
+	Fn      AggregateFn
+}
+
+type downsampler struct {
+	cfg DownsampleConfig
+}
+
+// NewDownsampler returns a Transformer that aggregates samples per
+// channel+name window using cfg. Unlike deadband, it keeps no state between
+// Transform calls: windows are built fresh from whatever batch it's given,
+// so a redelivered retry of an identical batch reproduces identical output.
+// The tradeoff is that a window never spans a batch boundary, regardless of
+// cfg.Samples/cfg.Window.
+func NewDownsampler(cfg DownsampleConfig) Transformer {
+	return &downsampler{cfg: cfg}
+}
+
+type downsampleKey struct{ channel, name string }
+
+// pendingWindow is a not-yet-closed per-series window being accumulated as
+// Transform walks the batch.
+type pendingWindow struct {
+	key     downsampleKey
+	records []Record
+	start   float64
+	lastIdx int
+}
+
+// Transform groups batch into per-series windows, but emits each window's
+// aggregate at the point in the batch where it closes (full, expired, or -
+// for a window still open when the batch ends - in the order its series
+// last appeared), rather than grouping all of one series' output together.
+// That keeps aggregates from different series interleaved in the same
+// relative order their source records arrived in, per the Transformer
+// ordering contract.
+func (d *downsampler) Transform(batch []Record) ([]Record, error) {
+	open := make(map[downsampleKey]*pendingWindow)
+	var out []Record
+
+	for i, r := range batch {
+		k := downsampleKey{r.Channel, r.Name}
+		w, ok := open[k]
+		if !ok {
+			w = &pendingWindow{key: k, start: r.Time}
+			open[k] = w
+		}
+		w.records = append(w.records, r)
+		w.lastIdx = i
+
+		full := d.cfg.Samples > 0 && len(w.records) >= d.cfg.Samples
+		expired := d.cfg.Window > 0 && r.Time-w.start >= d.cfg.Window
+		if full || expired {
+			out = append(out, d.aggregate(w.records))
+			delete(open, k)
+		}
+	}
+
+	if len(open) > 0 {
+		remaining := make([]*pendingWindow, 0, len(open))
+		for _, w := range open {
+			remaining = append(remaining, w)
+		}
+		sort.Slice(remaining, func(a, b int) bool { return remaining[a].lastIdx < remaining[b].lastIdx })
+		for _, w := range remaining {
+			out = append(out, d.aggregate(w.records))
+		}
+	}
+
+	return out, nil
+}
+
+func (d *downsampler) aggregate(window []Record) Record {
+	out := window[len(window)-1]
+
+	switch d.cfg.Fn {
+	case AggregateMin:
+		out.Value = window[0].Value
+		for _, r := range window {
+			if r.Value < out.Value {
+				out.Value = r.Value
+			}
+		}
+	case AggregateMax:
+		out.Value = window[0].Value
+		for _, r := range window {
+			if r.Value > out.Value {
+				out.Value = r.Value
+			}
+		}
+	case AggregateCount:
+		out.Value = float64(len(window))
+	default: // AggregateMean
+		var sum float64
+		for _, r := range window {
+			sum += r.Value
+		}
+		out.Value = sum / float64(len(window))
+	}
+
+	return out
+}
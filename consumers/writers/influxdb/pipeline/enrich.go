@@ -0,0 +1,93 @@
+// Copyright (c) Mainflux
+// SPDX-License-Identifier: Apache-2.0
+
+package pipeline
+
+import (
+	"sync"
+	"time"
+)
+
+// Metadata holds the subset of a thing/channel's attributes worth attaching
+// to written points.
+type Metadata map[string]string
+
+// MetadataFetcher resolves channel metadata, typically backed by a gRPC
+// call to the things service.
+type MetadataFetcher interface {
+	ChannelMetadata(channel string) (Metadata, error)
+}
+
+type cacheEntry struct {
+	meta    Metadata
+	expires time.Time
+}
+
+type enricher struct {
+	fetch MetadataFetcher
+	ttl   time.Duration
+
+	mu    sync.Mutex
+	cache map[string]cacheEntry
+}
+
+// NewEnricher returns a Transformer that joins each record's channel
+// metadata, fetched through fetch and cached for ttl, onto the record: the
+// "name" key fills Name when the record didn't already carry one, and every
+// key (including "name") is copied into Tags so the writer can persist the
+// rest of the metadata as InfluxDB tags. Lookup failures leave the record
+// unmodified rather than dropping it, so a transient things-service outage
+// degrades gracefully.
+func NewEnricher(fetch MetadataFetcher, ttl time.Duration) Transformer {
+	return &enricher{fetch: fetch, ttl: ttl, cache: make(map[string]cacheEntry)}
+}
+
+func (e *enricher) Transform(batch []Record) ([]Record, error) {
+	out := make([]Record, len(batch))
+	for i, r := range batch {
+		meta, err := e.metadataFor(r.Channel)
+		if err != nil {
+			out[i] = r
+			continue
+		}
+
+		if name, ok := meta["name"]; ok && r.Name == "" {
+			r.Name = name
+		}
+
+		if len(meta) > 0 {
+			tags := make(map[string]string, len(r.Tags)+len(meta))
+			for k, v := range r.Tags {
+				tags[k] = v
+			}
+			for k, v := range meta {
+				tags[k] = v
+			}
+			r.Tags = tags
+		}
+
+		out[i] = r
+	}
+
+	return out, nil
+}
+
+func (e *enricher) metadataFor(channel string) (Metadata, error) {
+	e.mu.Lock()
+	entry, ok := e.cache[channel]
+	e.mu.Unlock()
+	if ok && time.Now().Before(entry.expires) {
+		return entry.meta, nil
+	}
+
+	meta, err := e.fetch.ChannelMetadata(channel)
+	if err != nil {
+		return nil, err
+	}
+
+	e.mu.Lock()
+	e.cache[channel] = cacheEntry{meta: meta, expires: time.Now().Add(e.ttl)}
+	e.mu.Unlock()
+
+	return meta, nil
+}
@@ -0,0 +1,69 @@
+// Copyright (c) Mainflux
+// SPDX-License-Identifier: Apache-2.0
+
+// Package pipeline implements a Kapacitor-style stream processing pipeline
+// for the InfluxDB writer: an ordered sequence of Transformer stages, each
+// receiving the batch produced by the previous one, run before messages are
+// persisted.
+package pipeline
+
+// Record is the pipeline's unit of work: a single SenML-derived sample in a
+// batch, decoupled from senml.Message so stages don't need to depend on the
+// wire transformer package.
+//
+// Value always carries a numeric reading so numeric stages (deadband,
+// downsample, unit_conversion) have something to operate on; for records
+// whose SenML value is itself non-numeric, StringValue/BoolValue/DataValue
+// carries the original value through to the writer alongside it.
+type Record struct {
+	Channel     string
+	Subtopic    string
+	Publisher   string
+	Protocol    string
+	Name        string
+	Unit        string
+	Value       float64
+	StringValue *string
+	BoolValue   *bool
+	DataValue   *string
+	Time        float64
+
+	// Tags carries additional InfluxDB tags onto the written point, beyond
+	// the fixed set (channel/subtopic/publisher/protocol/name) the writer
+	// always sets. A stage populating it (e.g. the enricher) must not rely
+	// on overwriting a fixed tag: the writer keeps those authoritative and
+	// only adds Tags entries that don't collide with one.
+	Tags map[string]string
+}
+
+// Transformer reshapes a batch of records before it reaches the writer. A
+// Transformer may drop, merge, or enrich records, but must preserve the
+// relative ordering of the records it keeps so downstream stages (and
+// retries) remain deterministic.
+type Transformer interface {
+	Transform(batch []Record) ([]Record, error)
+}
+
+// Pipeline runs a batch through an ordered list of Transformer stages.
+type Pipeline struct {
+	stages []Transformer
+}
+
+// New returns a Pipeline that runs stages, in order, over every batch.
+func New(stages []Transformer) Pipeline {
+	return Pipeline{stages: stages}
+}
+
+// Run passes batch through every configured stage in order, returning the
+// result of the last stage (or batch unchanged if no stages are configured).
+func (p Pipeline) Run(batch []Record) ([]Record, error) {
+	var err error
+	for _, stage := range p.stages {
+		batch, err = stage.Transform(batch)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return batch, nil
+}
@@ -0,0 +1,46 @@
+// Copyright (c) Mainflux
+// SPDX-License-Identifier: Apache-2.0
+
+package pipeline
+
+// RetagConfig declares how records are reshaped before they reach InfluxDB.
+// RenameName maps a record's Name to a new Name, which is common when a
+// device publishes a more verbose field name than operators want surfaced
+// as a measurement name. DropUnit drops every whole record whose Unit is
+// listed; no single field or tag is removed from a record that is kept.
+type RetagConfig struct {
+	RenameName map[string]string
+	DropUnit   []string
+}
+
+type retagger struct {
+	cfg      RetagConfig
+	dropUnit map[string]struct{}
+}
+
+// NewRetagger returns a Transformer applying cfg's rename/drop rules.
+func NewRetagger(cfg RetagConfig) Transformer {
+	drop := make(map[string]struct{}, len(cfg.DropUnit))
+	for _, u := range cfg.DropUnit {
+		drop[u] = struct{}{}
+	}
+
+	return &retagger{cfg: cfg, dropUnit: drop}
+}
+
+func (t *retagger) Transform(batch []Record) ([]Record, error) {
+	out := make([]Record, 0, len(batch))
+	for _, r := range batch {
+		if _, drop := t.dropUnit[r.Unit]; drop {
+			continue
+		}
+
+		if renamed, ok := t.cfg.RenameName[r.Name]; ok {
+			r.Name = renamed
+		}
+
+		out = append(out, r)
+	}
+
+	return out, nil
+}
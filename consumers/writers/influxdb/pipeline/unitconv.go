@@ -0,0 +1,45 @@
+// Copyright (c) Mainflux
+// SPDX-License-Identifier: Apache-2.0
+
+package pipeline
+
+// UnitConversionRule rescales a record's value from From to To via
+// Value*Factor + Offset, keyed by the SenML unit it applies to.
+type UnitConversionRule struct {
+	From   string
+	To     string
+	Factor float64
+	Offset float64
+}
+
+type unitConverter struct {
+	rules map[string]UnitConversionRule
+}
+
+// NewUnitConverter returns a Transformer that rescales record values
+// according to rules, matched by the record's Unit field.
+func NewUnitConverter(rules []UnitConversionRule) Transformer {
+	byUnit := make(map[string]UnitConversionRule, len(rules))
+	for _, r := range rules {
+		byUnit[r.From] = r
+	}
+
+	return &unitConverter{rules: byUnit}
+}
+
+func (u *unitConverter) Transform(batch []Record) ([]Record, error) {
+	out := make([]Record, len(batch))
+	for i, r := range batch {
+		rule, ok := u.rules[r.Unit]
+		if !ok {
+			out[i] = r
+			continue
+		}
+
+		r.Value = r.Value*rule.Factor + rule.Offset
+		r.Unit = rule.To
+		out[i] = r
+	}
+
+	return out, nil
+}
@@ -0,0 +1,180 @@
+// Copyright (c) Mainflux
+// SPDX-License-Identifier: Apache-2.0
+
+package influxdb
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	influxdata "github.com/influxdata/influxdb-client-go/v2"
+	domain "github.com/influxdata/influxdb-client-go/v2/domain"
+	stdprometheus "github.com/prometheus/client_golang/prometheus"
+
+	"github.com/mainflux/mainflux/logger"
+	"github.com/mainflux/mainflux/pkg/errors"
+)
+
+var errReconcile = errors.New("failed to reconcile InfluxDB retention policies")
+
+// RollupBucket declares a continuous aggregation from a source bucket into
+// a rollup bucket kept for Retention, computed by grouping source points by
+// GroupBy tags and applying Aggregate over Window-sized buckets.
+type RollupBucket struct {
+	Name      string
+	Retention time.Duration
+	GroupBy   []string
+	Aggregate string
+	Window    time.Duration
+	Every     time.Duration
+}
+
+// RetentionConfig is the `[[rollup]]` section of a writer's TOML
+// configuration.
+type RetentionConfig struct {
+	SourceBucket string
+	Rollups      []RollupBucket
+}
+
+// RetentionManager ensures the rollup buckets and Flux continuous
+// aggregation tasks declared in a RetentionConfig exist in the target
+// InfluxDB v2 organization, reconciling on boot and on demand.
+type RetentionManager struct {
+	client influxdata.Client
+	org    string
+	cfg    RetentionConfig
+	logger logger.Logger
+
+	lastStatus stdprometheus.Gauge
+	lastRun    stdprometheus.Gauge
+}
+
+// NewRetentionManager returns a RetentionManager for cfg, scoped to org.
+func NewRetentionManager(client influxdata.Client, org string, cfg RetentionConfig, log logger.Logger) *RetentionManager {
+	return &RetentionManager{
+		client: client,
+		org:    org,
+		cfg:    cfg,
+		logger: log,
+		lastStatus: stdprometheus.NewGauge(stdprometheus.GaugeOpts{
+			Namespace: "influxdb_writer",
+			Subsystem: "retention",
+			Name:      "last_reconcile_success",
+			Help:      "Whether the last retention reconcile succeeded (1) or failed (0).",
+		}),
+		lastRun: stdprometheus.NewGauge(stdprometheus.GaugeOpts{
+			Namespace: "influxdb_writer",
+			Subsystem: "retention",
+			Name:      "last_reconcile_timestamp_seconds",
+			Help:      "Unix timestamp of the last retention reconcile attempt.",
+		}),
+	}
+}
+
+// Metrics returns the Prometheus collectors this manager exposes, for
+// registration by the caller.
+func (m *RetentionManager) Metrics() []stdprometheus.Collector {
+	return []stdprometheus.Collector{m.lastStatus, m.lastRun}
+}
+
+// Reconcile ensures every configured rollup bucket and its Flux task exist,
+// creating or updating them as needed. It is safe to call repeatedly - bucket
+// and task creation are both idempotent against the name.
+func (m *RetentionManager) Reconcile(ctx context.Context) error {
+	m.lastRun.Set(float64(time.Now().Unix()))
+
+	org, err := m.client.OrganizationsAPI().FindOrganizationByName(ctx, m.org)
+	if err != nil {
+		m.lastStatus.Set(0)
+		return errors.Wrap(errReconcile, err)
+	}
+
+	for _, r := range m.cfg.Rollups {
+		if err := m.reconcileBucket(ctx, org, r); err != nil {
+			m.lastStatus.Set(0)
+			return errors.Wrap(errReconcile, err)
+		}
+		if err := m.reconcileTask(ctx, *org.Id, r); err != nil {
+			m.lastStatus.Set(0)
+			return errors.Wrap(errReconcile, err)
+		}
+	}
+
+	m.lastStatus.Set(1)
+	return nil
+}
+
+func (m *RetentionManager) reconcileBucket(ctx context.Context, org *domain.Organization, r RollupBucket) error {
+	api := m.client.BucketsAPI()
+
+	existing, err := api.FindBucketByName(ctx, r.Name)
+	if err == nil && existing != nil {
+		existing.RetentionRules = retentionRules(r.Retention)
+		_, err := api.UpdateBucket(ctx, existing)
+		return err
+	}
+
+	rules := retentionRules(r.Retention)
+	_, err = api.CreateBucketWithNameWithID(ctx, *org.Id, r.Name, rules...)
+	return err
+}
+
+func (m *RetentionManager) reconcileTask(ctx context.Context, orgID string, r RollupBucket) error {
+	api := m.client.TasksAPI()
+	name := taskName(r)
+	flux := rollupFlux(m.cfg.SourceBucket, r)
+
+	tasks, err := api.FindTasks(ctx, &domain.TasksParams{Name: &name})
+	if err != nil {
+		return err
+	}
+	if len(tasks) > 0 {
+		task := tasks[0]
+		task.Flux = flux
+		_, err := api.UpdateTask(ctx, &task)
+		return err
+	}
+
+	every := r.Every.String()
+	_, err = api.CreateTaskWithEvery(ctx, name, flux, every, orgID)
+	return err
+}
+
+func retentionRules(d time.Duration) []domain.RetentionRule {
+	return []domain.RetentionRule{
+		{EverySeconds: int64(d.Seconds())},
+	}
+}
+
+func taskName(r RollupBucket) string {
+	return fmt.Sprintf("mainflux-rollup-%s", r.Name)
+}
+
+// rollupFlux generates a continuous aggregation query that reads from
+// source, groups by r.GroupBy, applies r.Aggregate over r.Window-sized
+// windows, and writes the result into r.Name.
+func rollupFlux(source string, r RollupBucket) string {
+	groupBy := "[]"
+	if len(r.GroupBy) > 0 {
+		groupBy = `["` + joinStrings(r.GroupBy, `", "`) + `"]`
+	}
+
+	return fmt.Sprintf(`from(bucket: "%s")
+  |> range(start: -%s)
+  |> group(columns: %s)
+  |> aggregateWindow(every: %s, fn: %s, createEmpty: false)
+  |> to(bucket: "%s")`,
+		source, r.Every.String(), groupBy, r.Window.String(), r.Aggregate, r.Name)
+}
+
+func joinStrings(ss []string, sep string) string {
+	out := ""
+	for i, s := range ss {
+		if i > 0 {
+			out += sep
+		}
+		out += s
+	}
+	return out
+}
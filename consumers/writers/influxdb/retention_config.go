@@ -0,0 +1,64 @@
+// Copyright (c) Mainflux
+// SPDX-License-Identifier: Apache-2.0
+
+package influxdb
+
+import (
+	"time"
+
+	"github.com/BurntSushi/toml"
+)
+
+type rollupTOML struct {
+	Name      string   `toml:"name"`
+	Retention string   `toml:"retention"`
+	GroupBy   []string `toml:"group_by"`
+	Aggregate string   `toml:"aggregate"`
+	Window    string   `toml:"window"`
+	Every     string   `toml:"every"`
+}
+
+type retentionTOML struct {
+	SourceBucket string       `toml:"source_bucket"`
+	Rollups      []rollupTOML `toml:"rollup"`
+}
+
+// LoadRetentionConfig parses the `[[rollup]]` section of the TOML file at
+// path into a RetentionConfig. It reads the same file as
+// pipeline.LoadConfig (MF_*_WRITER_CONFIG_PATH) rather than a config of
+// its own, so a rollup section is just one more block in a writer's
+// existing config.toml; a file with no `[[rollup]]` section decodes to a
+// zero-value RetentionConfig, not an error.
+func LoadRetentionConfig(path string) (RetentionConfig, error) {
+	var raw retentionTOML
+	if _, err := toml.DecodeFile(path, &raw); err != nil {
+		return RetentionConfig{}, err
+	}
+
+	cfg := RetentionConfig{SourceBucket: raw.SourceBucket}
+	for _, r := range raw.Rollups {
+		retention, err := time.ParseDuration(r.Retention)
+		if err != nil {
+			return RetentionConfig{}, err
+		}
+		window, err := time.ParseDuration(r.Window)
+		if err != nil {
+			return RetentionConfig{}, err
+		}
+		every, err := time.ParseDuration(r.Every)
+		if err != nil {
+			return RetentionConfig{}, err
+		}
+
+		cfg.Rollups = append(cfg.Rollups, RollupBucket{
+			Name:      r.Name,
+			Retention: retention,
+			GroupBy:   r.GroupBy,
+			Aggregate: r.Aggregate,
+			Window:    window,
+			Every:     every,
+		})
+	}
+
+	return cfg, nil
+}
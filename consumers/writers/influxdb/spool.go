@@ -0,0 +1,109 @@
+// Copyright (c) Mainflux
+// SPDX-License-Identifier: Apache-2.0
+
+package influxdb
+
+import (
+	"encoding/binary"
+	"sync"
+
+	bolt "go.etcd.io/bbolt"
+)
+
+var spoolBucket = []byte("spool")
+
+// spool persists write batches that could not be flushed to InfluxDB so
+// they can be replayed, in the order they were spooled, once the database
+// recovers.
+type spool struct {
+	db *bolt.DB
+	mu sync.Mutex
+}
+
+// openSpool opens (creating if necessary) a BoltDB-backed spool file.
+func openSpool(path string) (*spool, error) {
+	db, err := bolt.Open(path, 0600, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	err = db.Update(func(tx *bolt.Tx) error {
+		_, err := tx.CreateBucketIfNotExists(spoolBucket)
+		return err
+	})
+	if err != nil {
+		db.Close()
+		return nil, err
+	}
+
+	return &spool{db: db}, nil
+}
+
+// Save appends data as the newest spooled batch.
+func (s *spool) Save(data []byte) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return s.db.Update(func(tx *bolt.Tx) error {
+		b := tx.Bucket(spoolBucket)
+		seq, err := b.NextSequence()
+		if err != nil {
+			return err
+		}
+		return b.Put(keyFor(seq), data)
+	})
+}
+
+// Oldest returns the oldest spooled batch and its key, or ok=false if the
+// spool is empty.
+func (s *spool) Oldest() (key []byte, data []byte, ok bool, err error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	err = s.db.View(func(tx *bolt.Tx) error {
+		c := tx.Bucket(spoolBucket).Cursor()
+		k, v := c.First()
+		if k == nil {
+			return nil
+		}
+		ok = true
+		key = append([]byte(nil), k...)
+		data = append([]byte(nil), v...)
+		return nil
+	})
+
+	return key, data, ok, err
+}
+
+// Remove deletes a spooled batch once it has been replayed successfully.
+func (s *spool) Remove(key []byte) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return s.db.Update(func(tx *bolt.Tx) error {
+		return tx.Bucket(spoolBucket).Delete(key)
+	})
+}
+
+// Size returns the number of batches currently spooled.
+func (s *spool) Size() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	n := 0
+	s.db.View(func(tx *bolt.Tx) error {
+		n = tx.Bucket(spoolBucket).Stats().KeyN
+		return nil
+	})
+	return n
+}
+
+func (s *spool) Close() error {
+	return s.db.Close()
+}
+
+func keyFor(seq uint64) []byte {
+	k := make([]byte, 8)
+	binary.BigEndian.PutUint64(k, seq)
+	return k
+}
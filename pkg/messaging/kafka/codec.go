@@ -0,0 +1,20 @@
+// Copyright (c) Mainflux
+// SPDX-License-Identifier: Apache-2.0
+
+package kafka
+
+import (
+	"encoding/json"
+
+	"github.com/mainflux/mainflux/pkg/messaging"
+)
+
+func encode(msg messaging.Message) ([]byte, error) {
+	return json.Marshal(msg)
+}
+
+func decode(data []byte) (messaging.Message, error) {
+	var msg messaging.Message
+	err := json.Unmarshal(data, &msg)
+	return msg, err
+}
@@ -0,0 +1,81 @@
+// Copyright (c) Mainflux
+// SPDX-License-Identifier: Apache-2.0
+
+package kafka
+
+import (
+	"context"
+	"testing"
+
+	"github.com/mainflux/mainflux/pkg/messaging"
+	kafkago "github.com/segmentio/kafka-go"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type fakeHandler struct {
+	canceled bool
+}
+
+func (h *fakeHandler) Handle(msg messaging.Message) error { return nil }
+func (h *fakeHandler) Cancel() error {
+	h.canceled = true
+	return nil
+}
+
+func newTestSubscription() (*subscription, *fakeHandler) {
+	handler := &fakeHandler{}
+	_, cancel := context.WithCancel(context.Background())
+	reader := kafkago.NewReader(kafkago.ReaderConfig{Brokers: []string{"localhost:9092"}, Topic: "t", GroupID: "g"})
+	return &subscription{cancel: cancel, reader: reader, handler: handler}, handler
+}
+
+func TestUnsubscribeCancelsTheHandler(t *testing.T) {
+	sub, handler := newTestSubscription()
+	ps := &pubsub{subs: map[string]map[string]subscription{"t": {"id": *sub}}}
+
+	err := ps.Unsubscribe("id", "t")
+	require.Nil(t, err)
+	assert.True(t, handler.canceled, "Unsubscribe must call the subscription's handler.Cancel()")
+}
+
+func TestCloseCancelsEveryHandler(t *testing.T) {
+	sub, handler := newTestSubscription()
+	ps := &pubsub{
+		subs:      map[string]map[string]subscription{"t": {"id": *sub}},
+		publisher: kafkago.NewWriter(kafkago.WriterConfig{Brokers: []string{"localhost:9092"}}),
+	}
+
+	require.Nil(t, ps.Close())
+	assert.True(t, handler.canceled, "Close must call every subscription's handler.Cancel()")
+}
+
+func TestTopicMapsChannelAndSubtopic(t *testing.T) {
+	cases := []struct {
+		desc      string
+		chanTopic string
+		want      string
+	}{
+		{desc: "channel only", chanTopic: "45", want: "channels.45"},
+		{desc: "channel with subtopic", chanTopic: "45.temperature", want: "channels.45.temperature"},
+	}
+
+	for _, tc := range cases {
+		assert.Equal(t, tc.want, topic(tc.chanTopic), tc.desc)
+	}
+}
+
+func TestConsumerGroupForSharesConfiguredGroupAcrossSubscribers(t *testing.T) {
+	// With a consumer group configured, every subscriber - however it
+	// identifies itself - joins the same group, so replicas of a service
+	// load-share a topic's partitions instead of each reading every message.
+	assert.Equal(t, "influxdb-writer", consumerGroupFor("influxdb-writer", "sub-1"))
+	assert.Equal(t, "influxdb-writer", consumerGroupFor("influxdb-writer", "sub-2"))
+}
+
+func TestConsumerGroupForDefaultsToSubscriberIDWithoutAConfiguredGroup(t *testing.T) {
+	// With no group configured, each subscriber gets its own group keyed by
+	// id, so it receives a full copy of the topic rather than sharing it.
+	assert.Equal(t, "sub-1", consumerGroupFor("", "sub-1"))
+	assert.Equal(t, "sub-2", consumerGroupFor("", "sub-2"))
+}
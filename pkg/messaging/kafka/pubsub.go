@@ -0,0 +1,268 @@
+// Copyright (c) Mainflux
+// SPDX-License-Identifier: Apache-2.0
+
+// Package kafka holds the implementation of the messaging.PubSub interface
+// backed by Apache Kafka. It is meant to be used as a drop-in replacement
+// for pkg/messaging/nats wherever a service only depends on the
+// messaging.PubSub abstraction.
+package kafka
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"io/ioutil"
+	"sync"
+
+	"github.com/mainflux/mainflux/logger"
+	"github.com/mainflux/mainflux/pkg/errors"
+	"github.com/mainflux/mainflux/pkg/messaging"
+	kafkago "github.com/segmentio/kafka-go"
+	"github.com/segmentio/kafka-go/sasl/plain"
+)
+
+const chansPrefix = "channels"
+
+var (
+	// ErrEmptyTopic is returned when the topic is empty.
+	ErrEmptyTopic = errors.New("empty topic")
+
+	// ErrEmptyID is returned when the subscription ID is empty.
+	ErrEmptyID = errors.New("empty ID")
+
+	// ErrNotSubscribed is returned when a consumer attempts to unsubscribe
+	// from a topic it never subscribed to.
+	ErrNotSubscribed = errors.New("not subscribed")
+
+	// errTLSCACerts is returned when TLSCACerts can't be read or doesn't
+	// contain a valid PEM-encoded certificate.
+	errTLSCACerts = errors.New("failed to load Kafka TLS CA certificates")
+
+	_ messaging.Publisher  = (*pubsub)(nil)
+	_ messaging.PubSub     = (*pubsub)(nil)
+	_ messaging.Subscriber = (*pubsub)(nil)
+)
+
+// Config holds the connection and security parameters for the Kafka broker.
+type Config struct {
+	Brokers      []string
+	ConsumerGrp  string
+	SASLUsername string
+	SASLPassword string
+	TLSEnabled   bool
+	TLSCACerts   string
+}
+
+type subscription struct {
+	cancel  context.CancelFunc
+	reader  *kafkago.Reader
+	handler messaging.MessageHandler
+}
+
+type pubsub struct {
+	cfg       Config
+	logger    logger.Logger
+	publisher *kafkago.Writer
+	mu        sync.Mutex
+	subs      map[string]map[string]subscription
+}
+
+// NewPubSub returns a messaging.PubSub implementation backed by Kafka.
+// queue is the consumer group every subscription joins, enabling multiple
+// replicas of the same service to share the load for a topic; when queue is
+// empty, each subscription uses its own id as its group.
+func NewPubSub(url, queue string, logger logger.Logger) (messaging.PubSub, error) {
+	return NewPubSubWithConfig(Config{Brokers: []string{url}, ConsumerGrp: queue}, logger)
+}
+
+// NewPubSubWithConfig returns a messaging.PubSub implementation backed by
+// Kafka, configured with TLS/SASL as provided in cfg.
+func NewPubSubWithConfig(cfg Config, logger logger.Logger) (messaging.PubSub, error) {
+	if len(cfg.Brokers) == 0 {
+		return nil, errors.New("at least one Kafka broker URL is required")
+	}
+
+	dialer, err := cfg.dialer()
+	if err != nil {
+		return nil, err
+	}
+
+	ps := &pubsub{
+		cfg:    cfg,
+		logger: logger,
+		subs:   make(map[string]map[string]subscription),
+		publisher: kafkago.NewWriter(kafkago.WriterConfig{
+			Brokers:  cfg.Brokers,
+			Balancer: &kafkago.Hash{},
+			Dialer:   dialer,
+		}),
+	}
+
+	return ps, nil
+}
+
+// dialer builds the kafka-go Dialer used for both producing and consuming,
+// applying TLS and SASL/PLAIN authentication as configured.
+func (c Config) dialer() (*kafkago.Dialer, error) {
+	d := &kafkago.Dialer{DualStack: true}
+	if c.TLSEnabled {
+		tlsCfg := &tls.Config{MinVersion: tls.VersionTLS12}
+		if c.TLSCACerts != "" {
+			caCert, err := ioutil.ReadFile(c.TLSCACerts)
+			if err != nil {
+				return nil, errors.Wrap(errTLSCACerts, err)
+			}
+			pool := x509.NewCertPool()
+			if !pool.AppendCertsFromPEM(caCert) {
+				return nil, errTLSCACerts
+			}
+			tlsCfg.RootCAs = pool
+		}
+		d.TLS = tlsCfg
+	}
+	if c.SASLUsername != "" {
+		d.SASLMechanism = plain.Mechanism{Username: c.SASLUsername, Password: c.SASLPassword}
+	}
+	return d, nil
+}
+
+// topic maps a Mainflux channel/subtopic pair onto a Kafka topic name.
+func topic(chanTopic string) string {
+	return chansPrefix + "." + chanTopic
+}
+
+// consumerGroupFor picks the Kafka consumer group a subscription reads with.
+// A configured group is shared by every subscriber, so replicas of the same
+// service load-share a topic's partitions instead of each receiving every
+// message; with no group configured, id is used instead, so each subscriber
+// gets its own group and a full copy of the topic.
+func consumerGroupFor(cfgGrp, id string) string {
+	if cfgGrp == "" {
+		return id
+	}
+	return cfgGrp
+}
+
+func (ps *pubsub) Publish(chanTopic string, msg messaging.Message) error {
+	if chanTopic == "" {
+		return ErrEmptyTopic
+	}
+
+	data, err := encode(msg)
+	if err != nil {
+		return err
+	}
+
+	return ps.publisher.WriteMessages(context.Background(), kafkago.Message{
+		Topic: topic(chanTopic),
+		Key:   []byte(msg.Channel + "." + msg.Subtopic),
+		Value: data,
+	})
+}
+
+func (ps *pubsub) Subscribe(id, chanTopic string, handler messaging.MessageHandler) error {
+	if chanTopic == "" {
+		return ErrEmptyTopic
+	}
+	if id == "" {
+		return ErrEmptyID
+	}
+
+	grp := consumerGroupFor(ps.cfg.ConsumerGrp, id)
+
+	dialer, err := ps.cfg.dialer()
+	if err != nil {
+		return err
+	}
+
+	reader := kafkago.NewReader(kafkago.ReaderConfig{
+		Brokers: ps.cfg.Brokers,
+		GroupID: grp,
+		Topic:   topic(chanTopic),
+		Dialer:  dialer,
+	})
+
+	ctx, cancel := context.WithCancel(context.Background())
+
+	ps.mu.Lock()
+	if ps.subs[chanTopic] == nil {
+		ps.subs[chanTopic] = make(map[string]subscription)
+	}
+	ps.subs[chanTopic][id] = subscription{cancel: cancel, reader: reader, handler: handler}
+	ps.mu.Unlock()
+
+	go ps.consume(ctx, reader, handler)
+
+	return nil
+}
+
+func (ps *pubsub) consume(ctx context.Context, reader *kafkago.Reader, handler messaging.MessageHandler) {
+	for {
+		kmsg, err := reader.ReadMessage(ctx)
+		if err != nil {
+			if ctx.Err() != nil {
+				return
+			}
+			ps.logger.Error(err.Error())
+			continue
+		}
+
+		msg, err := decode(kmsg.Value)
+		if err != nil {
+			ps.logger.Error(err.Error())
+			continue
+		}
+
+		if err := handler.Handle(msg); err != nil {
+			ps.logger.Error(err.Error())
+		}
+	}
+}
+
+func (ps *pubsub) Unsubscribe(id, chanTopic string) error {
+	if chanTopic == "" {
+		return ErrEmptyTopic
+	}
+	if id == "" {
+		return ErrEmptyID
+	}
+
+	ps.mu.Lock()
+	defer ps.mu.Unlock()
+
+	subs, ok := ps.subs[chanTopic]
+	if !ok {
+		return ErrNotSubscribed
+	}
+	sub, ok := subs[id]
+	if !ok {
+		return ErrNotSubscribed
+	}
+
+	sub.cancel()
+	err := sub.reader.Close()
+	if cerr := sub.handler.Cancel(); cerr != nil && err == nil {
+		err = cerr
+	}
+	delete(subs, id)
+	if len(subs) == 0 {
+		delete(ps.subs, chanTopic)
+	}
+
+	return err
+}
+
+func (ps *pubsub) Close() error {
+	ps.mu.Lock()
+	defer ps.mu.Unlock()
+
+	for _, subs := range ps.subs {
+		for _, sub := range subs {
+			sub.cancel()
+			sub.reader.Close()
+			sub.handler.Cancel()
+		}
+	}
+
+	return ps.publisher.Close()
+}
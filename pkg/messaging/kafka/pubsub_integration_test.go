@@ -0,0 +1,117 @@
+// Copyright (c) Mainflux
+// SPDX-License-Identifier: Apache-2.0
+
+//go:build integration
+// +build integration
+
+// This file holds integration tests that dial a real Kafka broker instead
+// of only exercising constructor/validation paths. They run under the
+// "integration" build tag (`go test -tags integration ./...`) against a
+// broker reachable at MF_KAFKA_TEST_BROKER (default localhost:9092,
+// matching the docker-compose service the rest of this repo's integration
+// suites expect to be brought up out-of-band), and skip rather than fail
+// when no broker is reachable, so `go test ./...` stays hermetic.
+package kafka_test
+
+import (
+	"fmt"
+	"net"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/mainflux/mainflux/pkg/messaging"
+	"github.com/mainflux/mainflux/pkg/messaging/kafka"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func testBroker(t *testing.T) string {
+	broker := os.Getenv("MF_KAFKA_TEST_BROKER")
+	if broker == "" {
+		broker = "localhost:9092"
+	}
+
+	conn, err := net.DialTimeout("tcp", broker, 500*time.Millisecond)
+	if err != nil {
+		t.Skipf("no Kafka broker reachable at %s (set MF_KAFKA_TEST_BROKER to point at one): %s", broker, err)
+	}
+	conn.Close()
+
+	return broker
+}
+
+type recordingHandler struct {
+	msgs chan messaging.Message
+}
+
+func (h *recordingHandler) Handle(msg messaging.Message) error {
+	h.msgs <- msg
+	return nil
+}
+
+func (h *recordingHandler) Cancel() error { return nil }
+
+func TestIntegrationPublishIsDeliveredToSubscriber(t *testing.T) {
+	broker := testBroker(t)
+
+	ps, err := kafka.NewPubSub(broker, "it-writers", nil)
+	require.Nil(t, err)
+	defer ps.Close()
+
+	handler := &recordingHandler{msgs: make(chan messaging.Message, 1)}
+	chanTopic := fmt.Sprintf("it.%d", time.Now().UnixNano())
+	require.Nil(t, ps.Subscribe("sub-1", chanTopic, handler))
+	defer ps.Unsubscribe("sub-1", chanTopic)
+
+	want := messaging.Message{Channel: "45", Publisher: "pub-1", Payload: []byte("payload")}
+	require.Nil(t, ps.Publish(chanTopic, want))
+
+	select {
+	case got := <-handler.msgs:
+		assert.Equal(t, want.Channel, got.Channel)
+		assert.Equal(t, want.Publisher, got.Publisher)
+		assert.Equal(t, want.Payload, got.Payload)
+	case <-time.After(10 * time.Second):
+		t.Fatal("expected the published message to be delivered to the subscriber")
+	}
+}
+
+func TestIntegrationConsumerGroupSharesLoadAcrossSubscribers(t *testing.T) {
+	broker := testBroker(t)
+
+	ps, err := kafka.NewPubSub(broker, "it-shared-group", nil)
+	require.Nil(t, err)
+	defer ps.Close()
+
+	chanTopic := fmt.Sprintf("it.%d", time.Now().UnixNano())
+	h1 := &recordingHandler{msgs: make(chan messaging.Message, 10)}
+	h2 := &recordingHandler{msgs: make(chan messaging.Message, 10)}
+	require.Nil(t, ps.Subscribe("sub-1", chanTopic, h1))
+	require.Nil(t, ps.Subscribe("sub-2", chanTopic, h2))
+	defer ps.Unsubscribe("sub-1", chanTopic)
+	defer ps.Unsubscribe("sub-2", chanTopic)
+
+	const n = 20
+	for i := 0; i < n; i++ {
+		require.Nil(t, ps.Publish(chanTopic, messaging.Message{Channel: "45", Payload: []byte(fmt.Sprintf("%d", i))}))
+	}
+
+	received := 0
+	deadline := time.After(15 * time.Second)
+	for received < n {
+		select {
+		case <-h1.msgs:
+			received++
+		case <-h2.msgs:
+			received++
+		case <-deadline:
+			t.Fatalf("expected %d messages shared across both subscribers in the configured group, got %d", n, received)
+		}
+	}
+
+	// Give any duplicate deliveries time to arrive before checking for them.
+	time.Sleep(time.Second)
+	assert.Empty(t, h1.msgs, "a configured consumer group should load-share, not give every subscriber a full copy")
+	assert.Empty(t, h2.msgs, "a configured consumer group should load-share, not give every subscriber a full copy")
+}
@@ -0,0 +1,36 @@
+// Copyright (c) Mainflux
+// SPDX-License-Identifier: Apache-2.0
+
+package kafka_test
+
+import (
+	"testing"
+
+	"github.com/mainflux/mainflux/pkg/messaging"
+	"github.com/mainflux/mainflux/pkg/messaging/kafka"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNewPubSubRequiresBrokers(t *testing.T) {
+	_, err := kafka.NewPubSubWithConfig(kafka.Config{}, nil)
+	require.Error(t, err, "expected NewPubSubWithConfig to fail without brokers")
+}
+
+func TestPublishEmptyTopic(t *testing.T) {
+	ps, err := kafka.NewPubSub("localhost:9092", "writers", nil)
+	require.Nil(t, err, "creating a pubsub with a broker URL should succeed")
+	defer ps.Close()
+
+	err = ps.Publish("", messaging.Message{})
+	assert.Equal(t, kafka.ErrEmptyTopic, err)
+}
+
+func TestSubscribeRequiresID(t *testing.T) {
+	ps, err := kafka.NewPubSub("localhost:9092", "writers", nil)
+	require.Nil(t, err, "creating a pubsub with a broker URL should succeed")
+	defer ps.Close()
+
+	err = ps.Subscribe("", "channels.1", nil)
+	assert.Equal(t, kafka.ErrEmptyID, err)
+}
@@ -0,0 +1,20 @@
+// Copyright (c) Mainflux
+// SPDX-License-Identifier: Apache-2.0
+
+package messaging
+
+// Message represents a message emitted by the Mainflux adapters layer.
+type Message struct {
+	Channel   string
+	Subtopic  string
+	Publisher string
+	Protocol  string
+	Payload   []byte
+	Created   int64
+
+	// ContentType is the publisher-supplied Content-Type of Payload, e.g.
+	// "application/senml+json" or "application/senml+cbor". It is empty
+	// for adapters that don't forward one, in which case consumers fall
+	// back to their own configured default.
+	ContentType string
+}
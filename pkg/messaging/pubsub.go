@@ -0,0 +1,42 @@
+// Copyright (c) Mainflux
+// SPDX-License-Identifier: Apache-2.0
+
+package messaging
+
+// MessageHandler represents a callback used to handle messages delivered by
+// a Subscriber.
+type MessageHandler interface {
+	// Handle handles the message passed to it.
+	Handle(msg Message) error
+
+	// Cancel is called when the subscription is canceled.
+	Cancel() error
+}
+
+// Publisher specifies a message publishing API.
+type Publisher interface {
+	// Publish publishes message to the stream.
+	Publish(topic string, msg Message) error
+
+	// Close gracefully closes the message publisher's connection.
+	Close() error
+}
+
+// Subscriber specifies a message subscription API.
+type Subscriber interface {
+	// Subscribe subscribes to the message stream and consumes messages.
+	Subscribe(id, topic string, handler MessageHandler) error
+
+	// Unsubscribe unsubscribes the subscriber with the given id from the
+	// topic.
+	Unsubscribe(id, topic string) error
+
+	// Close gracefully closes the message subscriber's connection.
+	Close() error
+}
+
+// PubSub represents aggregation interface for publisher and subscriber.
+type PubSub interface {
+	Publisher
+	Subscriber
+}
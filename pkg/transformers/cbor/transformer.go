@@ -0,0 +1,114 @@
+// Copyright (c) Mainflux
+// SPDX-License-Identifier: Apache-2.0
+
+// Package cbor provides a Mainflux message transformer for CBOR-encoded
+// payloads, for constrained devices that publish binary telemetry over
+// MQTT/CoAP instead of JSON.
+package cbor
+
+import (
+	"github.com/fxamacker/cbor/v2"
+	"github.com/mainflux/mainflux/pkg/errors"
+	"github.com/mainflux/mainflux/pkg/messaging"
+	"github.com/mainflux/mainflux/pkg/transformers"
+	"github.com/mainflux/mainflux/pkg/transformers/json"
+	"github.com/mainflux/mainflux/pkg/transformers/senml"
+)
+
+// ContentType is the content type of a generic CBOR-encoded object,
+// forwarded like pkg/transformers/json does.
+const ContentType = "application/cbor"
+
+// SenMLContentType is the content type of a CBOR-encoded array of SenML
+// records, mirroring the senml+json content type handled by
+// pkg/transformers/senml.
+const SenMLContentType = "application/senml+cbor"
+
+var errDecode = errors.New("failed to decode cbor payload")
+
+// senMLRecord is the CBOR wire representation of a senml.Message. It exists
+// so decoding doesn't depend on struct tags the external senml.Message type
+// doesn't declare.
+type senMLRecord struct {
+	Channel     string   `cbor:"channel"`
+	Subtopic    string   `cbor:"subtopic"`
+	Publisher   string   `cbor:"publisher"`
+	Protocol    string   `cbor:"protocol"`
+	Name        string   `cbor:"name"`
+	Unit        string   `cbor:"unit,omitempty"`
+	Value       *float64 `cbor:"value,omitempty"`
+	StringValue *string  `cbor:"stringValue,omitempty"`
+	DataValue   *string  `cbor:"dataValue,omitempty"`
+	BoolValue   *bool    `cbor:"boolValue,omitempty"`
+	Sum         *float64 `cbor:"sum,omitempty"`
+	Time        float64  `cbor:"time,omitempty"`
+	UpdateTime  float64  `cbor:"updateTime,omitempty"`
+}
+
+type transformer struct {
+	contentType string
+}
+
+// New returns a CBOR transformer. When contentType is SenMLContentType, the
+// payload is decoded as an array of SenML records, the same shape produced
+// by pkg/transformers/senml; otherwise the payload is decoded as an
+// arbitrary object and forwarded like pkg/transformers/json does.
+func New(contentType string) transformers.Transformer {
+	return transformer{contentType: contentType}
+}
+
+func (t transformer) Transform(msg messaging.Message) (interface{}, error) {
+	if t.contentType == SenMLContentType {
+		return t.transformSenML(msg)
+	}
+	return t.transformJSON(msg)
+}
+
+func (t transformer) transformSenML(msg messaging.Message) (interface{}, error) {
+	var records []senMLRecord
+	if err := cbor.Unmarshal(msg.Payload, &records); err != nil {
+		return nil, errors.Wrap(errDecode, err)
+	}
+
+	msgs := make([]senml.Message, len(records))
+	for i, r := range records {
+		msgs[i] = senml.Message{
+			Channel:     msg.Channel,
+			Subtopic:    msg.Subtopic,
+			Publisher:   msg.Publisher,
+			Protocol:    msg.Protocol,
+			Name:        r.Name,
+			Unit:        r.Unit,
+			Value:       r.Value,
+			StringValue: r.StringValue,
+			DataValue:   r.DataValue,
+			BoolValue:   r.BoolValue,
+			Sum:         r.Sum,
+			Time:        r.Time,
+			UpdateTime:  r.UpdateTime,
+		}
+	}
+
+	return msgs, nil
+}
+
+func (t transformer) transformJSON(msg messaging.Message) (interface{}, error) {
+	var payload map[string]interface{}
+	if err := cbor.Unmarshal(msg.Payload, &payload); err != nil {
+		return nil, errors.Wrap(errDecode, err)
+	}
+
+	return json.Messages{
+		Format: "cbor",
+		Data: []json.Message{
+			{
+				Channel:   msg.Channel,
+				Subtopic:  msg.Subtopic,
+				Publisher: msg.Publisher,
+				Protocol:  msg.Protocol,
+				Created:   msg.Created,
+				Payload:   payload,
+			},
+		},
+	}, nil
+}
@@ -0,0 +1,82 @@
+// Copyright (c) Mainflux
+// SPDX-License-Identifier: Apache-2.0
+
+package cbor_test
+
+import (
+	"testing"
+
+	fxcbor "github.com/fxamacker/cbor/v2"
+	"github.com/mainflux/mainflux/pkg/messaging"
+	"github.com/mainflux/mainflux/pkg/transformers/cbor"
+	"github.com/mainflux/mainflux/pkg/transformers/json"
+	"github.com/mainflux/mainflux/pkg/transformers/senml"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestTransformSenML(t *testing.T) {
+	val := 21.5
+	payload, err := fxcbor.Marshal([]map[string]interface{}{
+		{
+			"name":  "temperature",
+			"unit":  "C",
+			"value": val,
+			"time":  1000.0,
+		},
+	})
+	require.Nil(t, err, "marshaling the fixture payload should succeed")
+
+	msg := messaging.Message{
+		Channel:   "45",
+		Subtopic:  "s",
+		Publisher: "2580",
+		Protocol:  "mqtt",
+		Payload:   payload,
+	}
+
+	transformer := cbor.New(cbor.SenMLContentType)
+	res, err := transformer.Transform(msg)
+	require.Nil(t, err, "transforming a valid senml+cbor payload should succeed")
+
+	msgs, ok := res.([]senml.Message)
+	require.True(t, ok, "expected the senml+cbor transform to return []senml.Message")
+	require.Len(t, msgs, 1)
+	assert.Equal(t, msg.Channel, msgs[0].Channel)
+	assert.Equal(t, "temperature", msgs[0].Name)
+	assert.Equal(t, "C", msgs[0].Unit)
+	require.NotNil(t, msgs[0].Value)
+	assert.Equal(t, val, *msgs[0].Value)
+}
+
+func TestTransformGenericCBOR(t *testing.T) {
+	payload, err := fxcbor.Marshal(map[string]interface{}{
+		"field_1": 123,
+		"field_2": "value",
+	})
+	require.Nil(t, err, "marshaling the fixture payload should succeed")
+
+	msg := messaging.Message{
+		Channel:   "45",
+		Publisher: "2580",
+		Protocol:  "mqtt",
+		Payload:   payload,
+	}
+
+	transformer := cbor.New(cbor.ContentType)
+	res, err := transformer.Transform(msg)
+	require.Nil(t, err, "transforming a valid cbor payload should succeed")
+
+	msgs, ok := res.(json.Messages)
+	require.True(t, ok, "expected the generic cbor transform to return json.Messages")
+	require.Len(t, msgs.Data, 1)
+	assert.Equal(t, "cbor", msgs.Format)
+	assert.Equal(t, "value", msgs.Data[0].Payload["field_2"])
+}
+
+func TestTransformInvalidPayloadFails(t *testing.T) {
+	msg := messaging.Message{Channel: "45", Payload: []byte("not cbor")}
+
+	_, err := cbor.New(cbor.SenMLContentType).Transform(msg)
+	assert.NotNil(t, err, "transforming a malformed payload should fail")
+}
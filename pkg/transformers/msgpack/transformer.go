@@ -0,0 +1,111 @@
+// Copyright (c) Mainflux
+// SPDX-License-Identifier: Apache-2.0
+
+// Package msgpack provides a Mainflux message transformer for
+// MessagePack-encoded payloads, for constrained devices that publish
+// binary telemetry over MQTT/CoAP instead of JSON.
+package msgpack
+
+import (
+	"github.com/mainflux/mainflux/pkg/errors"
+	"github.com/mainflux/mainflux/pkg/messaging"
+	"github.com/mainflux/mainflux/pkg/transformers"
+	"github.com/mainflux/mainflux/pkg/transformers/json"
+	"github.com/mainflux/mainflux/pkg/transformers/senml"
+	"github.com/vmihailenco/msgpack/v5"
+)
+
+// ContentType is the content type of a generic MessagePack-encoded object,
+// forwarded like pkg/transformers/json does.
+const ContentType = "application/vnd.msgpack"
+
+// SenMLContentType is the content type of a MessagePack-encoded array of
+// SenML records, mirroring the senml+json content type handled by
+// pkg/transformers/senml.
+const SenMLContentType = "application/senml+msgpack"
+
+var errDecode = errors.New("failed to decode msgpack payload")
+
+// senMLRecord is the MessagePack wire representation of a senml.Message. It
+// exists so decoding doesn't depend on struct tags the external
+// senml.Message type doesn't declare.
+type senMLRecord struct {
+	Name        string   `msgpack:"name"`
+	Unit        string   `msgpack:"unit,omitempty"`
+	Value       *float64 `msgpack:"value,omitempty"`
+	StringValue *string  `msgpack:"stringValue,omitempty"`
+	DataValue   *string  `msgpack:"dataValue,omitempty"`
+	BoolValue   *bool    `msgpack:"boolValue,omitempty"`
+	Sum         *float64 `msgpack:"sum,omitempty"`
+	Time        float64  `msgpack:"time,omitempty"`
+	UpdateTime  float64  `msgpack:"updateTime,omitempty"`
+}
+
+type transformer struct {
+	contentType string
+}
+
+// New returns a MessagePack transformer. When contentType is
+// SenMLContentType, the payload is decoded as an array of SenML records,
+// the same shape produced by pkg/transformers/senml; otherwise the payload
+// is decoded as an arbitrary object and forwarded like
+// pkg/transformers/json does.
+func New(contentType string) transformers.Transformer {
+	return transformer{contentType: contentType}
+}
+
+func (t transformer) Transform(msg messaging.Message) (interface{}, error) {
+	if t.contentType == SenMLContentType {
+		return t.transformSenML(msg)
+	}
+	return t.transformJSON(msg)
+}
+
+func (t transformer) transformSenML(msg messaging.Message) (interface{}, error) {
+	var records []senMLRecord
+	if err := msgpack.Unmarshal(msg.Payload, &records); err != nil {
+		return nil, errors.Wrap(errDecode, err)
+	}
+
+	msgs := make([]senml.Message, len(records))
+	for i, r := range records {
+		msgs[i] = senml.Message{
+			Channel:     msg.Channel,
+			Subtopic:    msg.Subtopic,
+			Publisher:   msg.Publisher,
+			Protocol:    msg.Protocol,
+			Name:        r.Name,
+			Unit:        r.Unit,
+			Value:       r.Value,
+			StringValue: r.StringValue,
+			DataValue:   r.DataValue,
+			BoolValue:   r.BoolValue,
+			Sum:         r.Sum,
+			Time:        r.Time,
+			UpdateTime:  r.UpdateTime,
+		}
+	}
+
+	return msgs, nil
+}
+
+func (t transformer) transformJSON(msg messaging.Message) (interface{}, error) {
+	var payload map[string]interface{}
+	if err := msgpack.Unmarshal(msg.Payload, &payload); err != nil {
+		return nil, errors.Wrap(errDecode, err)
+	}
+
+	return json.Messages{
+		Format: "msgpack",
+		Data: []json.Message{
+			{
+				Channel:   msg.Channel,
+				Subtopic:  msg.Subtopic,
+				Publisher: msg.Publisher,
+				Protocol:  msg.Protocol,
+				Created:   msg.Created,
+				Payload:   payload,
+			},
+		},
+	}, nil
+}
@@ -0,0 +1,82 @@
+// Copyright (c) Mainflux
+// SPDX-License-Identifier: Apache-2.0
+
+package msgpack_test
+
+import (
+	"testing"
+
+	"github.com/mainflux/mainflux/pkg/messaging"
+	"github.com/mainflux/mainflux/pkg/transformers/json"
+	"github.com/mainflux/mainflux/pkg/transformers/msgpack"
+	"github.com/mainflux/mainflux/pkg/transformers/senml"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	vmpack "github.com/vmihailenco/msgpack/v5"
+)
+
+func TestTransformSenML(t *testing.T) {
+	val := 21.5
+	payload, err := vmpack.Marshal([]map[string]interface{}{
+		{
+			"name":  "temperature",
+			"unit":  "C",
+			"value": val,
+			"time":  1000.0,
+		},
+	})
+	require.Nil(t, err, "marshaling the fixture payload should succeed")
+
+	msg := messaging.Message{
+		Channel:   "45",
+		Subtopic:  "s",
+		Publisher: "2580",
+		Protocol:  "mqtt",
+		Payload:   payload,
+	}
+
+	transformer := msgpack.New(msgpack.SenMLContentType)
+	res, err := transformer.Transform(msg)
+	require.Nil(t, err, "transforming a valid senml+msgpack payload should succeed")
+
+	msgs, ok := res.([]senml.Message)
+	require.True(t, ok, "expected the senml+msgpack transform to return []senml.Message")
+	require.Len(t, msgs, 1)
+	assert.Equal(t, msg.Channel, msgs[0].Channel)
+	assert.Equal(t, "temperature", msgs[0].Name)
+	assert.Equal(t, "C", msgs[0].Unit)
+	require.NotNil(t, msgs[0].Value)
+	assert.Equal(t, val, *msgs[0].Value)
+}
+
+func TestTransformGenericMsgpack(t *testing.T) {
+	payload, err := vmpack.Marshal(map[string]interface{}{
+		"field_1": 123,
+		"field_2": "value",
+	})
+	require.Nil(t, err, "marshaling the fixture payload should succeed")
+
+	msg := messaging.Message{
+		Channel:   "45",
+		Publisher: "2580",
+		Protocol:  "mqtt",
+		Payload:   payload,
+	}
+
+	transformer := msgpack.New(msgpack.ContentType)
+	res, err := transformer.Transform(msg)
+	require.Nil(t, err, "transforming a valid msgpack payload should succeed")
+
+	msgs, ok := res.(json.Messages)
+	require.True(t, ok, "expected the generic msgpack transform to return json.Messages")
+	require.Len(t, msgs.Data, 1)
+	assert.Equal(t, "msgpack", msgs.Format)
+	assert.Equal(t, "value", msgs.Data[0].Payload["field_2"])
+}
+
+func TestTransformInvalidPayloadFails(t *testing.T) {
+	msg := messaging.Message{Channel: "45", Payload: []byte("not msgpack")}
+
+	_, err := msgpack.New(msgpack.SenMLContentType).Transform(msg)
+	assert.NotNil(t, err, "transforming a malformed payload should fail")
+}
@@ -0,0 +1,16 @@
+// Copyright (c) Mainflux
+// SPDX-License-Identifier: Apache-2.0
+
+// Package transformers contains the interface shared by every payload
+// transformer consumed by Mainflux message writers and notifiers.
+package transformers
+
+import "github.com/mainflux/mainflux/pkg/messaging"
+
+// Transformer specifies the API for converting a raw Mainflux message into
+// the representation a particular consumer understands, e.g. SenML or JSON
+// records.
+type Transformer interface {
+	// Transform transforms a Mainflux message into any other format.
+	Transform(msg messaging.Message) (interface{}, error)
+}